@@ -0,0 +1,94 @@
+package clusters
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	cacheDirName      = "gogok8s"
+	cacheFilename     = "clusters.json"
+	cacheDirFilemode  = os.FileMode(0o700)
+	cacheFileFilemode = os.FileMode(0o600)
+)
+
+// ClusterCache stores the clusters found by the most recent scan of each account, so that they can be listed without
+// calling AWS.
+type ClusterCache struct {
+	Accounts map[string]CachedAccount `json:"accounts"`
+}
+
+type CachedAccount struct {
+	ScannedAt time.Time     `json:"scannedAt"`
+	Clusters  []ClusterInfo `json:"clusters"`
+}
+
+// LoadClusterCache reads the cluster cache, returning an empty cache when none has been written yet.
+func LoadClusterCache() (*ClusterCache, error) {
+	cache := &ClusterCache{Accounts: make(map[string]CachedAccount)}
+
+	filename, err := getCacheFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read cluster cache: %w", err)
+	}
+
+	if err = json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster cache: %w", err)
+	}
+
+	if cache.Accounts == nil {
+		cache.Accounts = make(map[string]CachedAccount)
+	}
+
+	return cache, nil
+}
+
+// Update replaces the cached clusters of an account.
+func (c *ClusterCache) Update(account string, clusters []ClusterInfo, scannedAt time.Time) {
+	c.Accounts[account] = CachedAccount{
+		ScannedAt: scannedAt,
+		Clusters:  clusters,
+	}
+}
+
+func (c *ClusterCache) Save() error {
+	filename, err := getCacheFilePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster cache: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(filename), cacheDirFilemode); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err = os.WriteFile(filename, data, cacheFileFilemode); err != nil {
+		return fmt.Errorf("failed to write cluster cache: %w", err)
+	}
+
+	return nil
+}
+
+func getCacheFilePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, cacheDirName, cacheFilename), nil
+}
@@ -6,5 +6,19 @@ import (
 
 type ClusterAccount interface {
 	GenerateKubeConfig() (*kubecfg.KubeConfigPatch, []error)
+	ListClusters() ([]ClusterInfo, []error)
 	PrettyName() string
+	ListRegions() []string
+}
+
+// ClusterInfo describes a discovered cluster along with the names of the kubeconfig contexts generated for it.
+type ClusterInfo struct {
+	Account  string   `json:"account" yaml:"account"`
+	Region   string   `json:"region" yaml:"region"`
+	Name     string   `json:"name" yaml:"name"`
+	Arn      string   `json:"arn" yaml:"arn"`
+	Endpoint string   `json:"endpoint" yaml:"endpoint"`
+	Version  string   `json:"version" yaml:"version"`
+	Status   string   `json:"status" yaml:"status"`
+	Contexts []string `json:"contexts" yaml:"contexts"`
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
@@ -14,11 +17,18 @@ import (
 )
 
 type EKSAccount struct {
-	Profile    string    `yaml:"profile"`
-	Regions    []string  `yaml:"regions"`
-	Name       string    `yaml:"name"`
-	Format     string    `yaml:"format"`
-	ExtraUsers []EKSUser `yaml:"extraUsers,omitempty"`
+	// The AWS profile used for the account, which can differ per region using ${region}, e.g. team-${region}.
+	Profile string   `yaml:"profile,omitempty"`
+	Regions []string `yaml:"regions,omitempty"`
+	// Regions scanned on top of Regions, or on top of the default regions when Regions is unset.
+	AdditionalRegions []string  `yaml:"additionalRegions,omitempty"`
+	Name              string    `yaml:"name"`
+	Format            string    `yaml:"format,omitempty"`
+	ExtraUsers        []EKSUser `yaml:"extraUsers,omitempty"`
+	// Marks the account as production, which `gogok8s prompt` highlights.
+	Production bool `yaml:"production,omitempty"`
+	// Labels select accounts with `gogok8s sync -l`, e.g. env: prod.
+	Labels map[string]string `yaml:"labels,omitempty"`
 }
 
 type EKSUser struct {
@@ -32,6 +42,8 @@ type EKSClusterConfig struct {
 	Server                   string
 	CertificateAuthorityData []byte
 	Arn                      string
+	Version                  string
+	Status                   string
 }
 
 type describeEKSResult struct {
@@ -58,20 +70,22 @@ type EKSClusterAPI interface {
 }
 
 const (
+	// AuthenticatorCommand is the command kubeconfig users generated by gogok8s run to fetch a token.
+	AuthenticatorCommand = "aws-iam-authenticator"
+
+	// DefaultFormat is the format of generated kubeconfig entry names when an account doesn't set one.
+	DefaultFormat = "${name}.${region}.${clusterName}"
+
+	// RegionVariable is replaced with the region within profiles.
+	RegionVariable = "${region}"
+
 	defaultTimeout = 30 * time.Second
-	defaultFormat  = "${name}.${region}.${clusterName}"
 )
 
 func (a EKSAccount) GenerateKubeConfig() (*kubecfg.KubeConfigPatch, []error) {
 	accountKubeConfig := &kubecfg.KubeConfigPatch{}
 
-	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithSharedConfigProfile(a.Profile))
-	if err != nil {
-		return accountKubeConfig, []error{err}
-	}
-
-	client := eks.NewFromConfig(cfg)
-	clusters, errors := a.ScanForClusters(client)
+	clusters, errors := a.scan()
 
 	for _, cluster := range clusters {
 		patch := a.generateKubeConfigFromCluster(cluster)
@@ -83,15 +97,120 @@ func (a EKSAccount) GenerateKubeConfig() (*kubecfg.KubeConfigPatch, []error) {
 	return accountKubeConfig, errors
 }
 
-func (a EKSAccount) PrettyName() string {
-	return a.Name
+func (a EKSAccount) ListClusters() ([]ClusterInfo, []error) {
+	clusters, errors := a.scan()
+
+	infos := make([]ClusterInfo, 0, len(clusters))
+	for _, cluster := range clusters {
+		infos = append(infos, a.clusterInfo(cluster))
+	}
+
+	return infos, errors
 }
 
-func (a EKSAccount) generateKubeConfigFromCluster(cluster EKSClusterConfig) *kubecfg.KubeConfigPatch {
-	patch := &kubecfg.KubeConfigPatch{}
+func (a EKSAccount) clusterInfo(cluster EKSClusterConfig) ClusterInfo {
+	return ClusterInfo{
+		Account:  a.Name,
+		Region:   cluster.Region,
+		Name:     cluster.Name,
+		Arn:      cluster.Arn,
+		Endpoint: cluster.Server,
+		Version:  cluster.Version,
+		Status:   cluster.Status,
+		Contexts: a.ContextNames(cluster),
+	}
+}
 
-	var clusterName, userName, contextName string
+// ContextNames returns the names of the kubeconfig contexts generated for the cluster.
+func (a EKSAccount) ContextNames(cluster EKSClusterConfig) []string {
+	contextName := a.formatName(cluster)
+
+	names := []string{contextName}
+	for _, user := range a.ExtraUsers {
+		names = append(names, contextName+"."+user.Name)
+	}
+
+	return names
+}
+
+func (a EKSAccount) scan() ([]EKSClusterConfig, []error) {
+	if !strings.Contains(a.Profile, RegionVariable) {
+		client, err := newEKSClient(a.Profile)
+		if err != nil {
+			return nil, []error{err}
+		}
+
+		return a.ScanForClusters(client)
+	}
+
+	// The profile differs per region, so every region is scanned with a client of its own
+	ch := make(chan scanForClustersResult, len(a.Regions))
+
+	for _, region := range a.Regions {
+		go func(region string) {
+			client, err := newEKSClient(a.ProfileFor(region))
+			if err != nil {
+				ch <- scanForClustersResult{Errors: []error{fmt.Errorf("region='%s': %w", region, err)}}
+
+				return
+			}
+
+			scanForClustersInRegion(region, client, ch)
+		}(region)
+	}
+
+	return collectScanResults(ch, len(a.Regions))
+}
+
+func newEKSClient(profile string) (EKSClusterAPI, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithSharedConfigProfile(profile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return eks.NewFromConfig(cfg), nil
+}
+
+// ProfileFor returns the profile the account uses in the region.
+func (a EKSAccount) ProfileFor(region string) string {
+	return strings.ReplaceAll(a.Profile, RegionVariable, region)
+}
+
+// ProfileFor returns the profile the user uses in the region.
+func (u EKSUser) ProfileFor(region string) string {
+	return strings.ReplaceAll(u.Profile, RegionVariable, region)
+}
+
+// ListProfiles returns every profile the account and its extra users use across its regions.
+func (a EKSAccount) ListProfiles() []string {
+	var profiles []string
+
+	add := func(profile string) {
+		if !strings.Contains(profile, RegionVariable) {
+			if !slices.Contains(profiles, profile) {
+				profiles = append(profiles, profile)
+			}
+
+			return
+		}
 
+		for _, region := range a.Regions {
+			if resolved := strings.ReplaceAll(profile, RegionVariable, region); !slices.Contains(profiles, resolved) {
+				profiles = append(profiles, resolved)
+			}
+		}
+	}
+
+	add(a.Profile)
+
+	for _, user := range a.ExtraUsers {
+		add(user.Profile)
+	}
+
+	return profiles
+}
+
+func (a EKSAccount) formatName(cluster EKSClusterConfig) string {
 	replacements := map[string]string{
 		"${name}":        a.Name,
 		"${region}":      cluster.Region,
@@ -99,38 +218,119 @@ func (a EKSAccount) generateKubeConfigFromCluster(cluster EKSClusterConfig) *kub
 		"${clusterArn}":  cluster.Arn,
 	}
 
-	formattedName := formatName(a.Format, replacements)
+	return formatName(a.Format, replacements)
+}
+
+// EntryName returns the name of the kubeconfig entry generated for the cluster described by the metadata, used to
+// rename existing entries when the account is renamed.
+func (a EKSAccount) EntryName(metadata kubecfg.Metadata) string {
+	name := a.formatName(EKSClusterConfig{
+		Name:   metadata.ClusterName,
+		Region: metadata.Region,
+		Arn:    metadata.ClusterArn,
+	})
+
+	if metadata.ExtraUser != "" {
+		name += "." + metadata.ExtraUser
+	}
+
+	return name
+}
+
+// ParseEntryName reverses EntryName, recovering the metadata of a kubeconfig entry generated for the account from its
+// name alone. It is used for entries written before gogok8s stored metadata within the kubeconfig.
+func (a EKSAccount) ParseEntryName(name string) (kubecfg.Metadata, bool) {
+	users := make([]string, 0, len(a.ExtraUsers))
+	for _, user := range a.ExtraUsers {
+		users = append(users, user.Name)
+	}
+
+	values, ok := parseName(a.Format, name, map[string]string{"${name}": a.Name}, users)
+	if !ok {
+		return kubecfg.Metadata{}, false
+	}
+
+	metadata := kubecfg.Metadata{
+		Account:     a.Name,
+		Region:      values["region"],
+		ClusterName: values["clusterName"],
+		ClusterArn:  values["clusterArn"],
+		ExtraUser:   values["suffix"],
+	}
+
+	// Formats using only the ARN still identify the region and cluster
+	if arn := strings.Split(metadata.ClusterArn, ":"); len(arn) == 6 {
+		if metadata.Region == "" {
+			metadata.Region = arn[3]
+		}
+
+		if metadata.ClusterName == "" {
+			metadata.ClusterName = strings.TrimPrefix(arn[5], "cluster/")
+		}
+	}
+
+	return metadata, true
+}
+
+func (a EKSAccount) PrettyName() string {
+	return a.Name
+}
+
+func (a EKSAccount) ListRegions() []string {
+	return a.Regions
+}
+
+func (a EKSAccount) generateKubeConfigFromCluster(cluster EKSClusterConfig) *kubecfg.KubeConfigPatch {
+	patch := &kubecfg.KubeConfigPatch{}
+
+	var clusterName, userName, contextName string
+
+	formattedName := a.formatName(cluster)
 	clusterName = formattedName
 	userName = formattedName
 	contextName = formattedName
 
+	metadata := kubecfg.Metadata{
+		Account:     a.Name,
+		Region:      cluster.Region,
+		ClusterName: cluster.Name,
+		ClusterArn:  cluster.Arn,
+	}
+
 	patch.Clusters = append(patch.Clusters, &v1.NamedCluster{
 		Name: clusterName,
 		Cluster: v1.Cluster{
 			Server:                   cluster.Server,
 			CertificateAuthorityData: cluster.CertificateAuthorityData,
+			Extensions:               kubecfg.NewMetadataExtensions(metadata),
 		},
 	})
 
 	patch.Users = append(patch.Users, &v1.NamedAuthInfo{
 		Name: userName,
 		AuthInfo: v1.AuthInfo{
-			Exec: generateIAMAuthenticatorExecConfig(cluster, a.Profile),
+			Exec:       generateIAMAuthenticatorExecConfig(cluster, a.ProfileFor(cluster.Region)),
+			Extensions: kubecfg.NewMetadataExtensions(metadata),
 		},
 	})
 
 	for _, user := range a.ExtraUsers {
+		userMetadata := metadata
+		userMetadata.ExtraUser = user.Name
+
 		patch.Users = append(patch.Users, &v1.NamedAuthInfo{
 			Name: userName + "." + user.Name,
 			AuthInfo: v1.AuthInfo{
-				Exec: generateIAMAuthenticatorExecConfig(cluster, user.Profile),
+				Exec:       generateIAMAuthenticatorExecConfig(cluster, user.ProfileFor(cluster.Region)),
+				Extensions: kubecfg.NewMetadataExtensions(userMetadata),
 			},
 		})
 		patch.Contexts = append(patch.Contexts, &v1.NamedContext{
 			Name: contextName + "." + user.Name,
 			Context: v1.Context{
-				Cluster:  clusterName,
-				AuthInfo: userName + "." + user.Name,
+				Cluster:    clusterName,
+				AuthInfo:   userName + "." + user.Name,
+				Extensions: kubecfg.NewMetadataExtensions(userMetadata),
 			},
 		})
 	}
@@ -138,8 +338,9 @@ func (a EKSAccount) generateKubeConfigFromCluster(cluster EKSClusterConfig) *kub
 	patch.Contexts = append(patch.Contexts, &v1.NamedContext{
 		Name: contextName,
 		Context: v1.Context{
-			Cluster:  clusterName,
-			AuthInfo: userName,
+			Cluster:    clusterName,
+			AuthInfo:   userName,
+			Extensions: kubecfg.NewMetadataExtensions(metadata),
 		},
 	})
 
@@ -153,11 +354,15 @@ func (a EKSAccount) ScanForClusters(client EKSClusterAPI) ([]EKSClusterConfig, [
 		go scanForClustersInRegion(region, client, ch)
 	}
 
+	return collectScanResults(ch, len(a.Regions))
+}
+
+func collectScanResults(ch chan scanForClustersResult, regions int) ([]EKSClusterConfig, []error) {
 	var clusters []EKSClusterConfig
 
 	var errors []error
 
-	for range a.Regions {
+	for range regions {
 		result := <-ch
 		clusters = append(clusters, result.Clusters...)
 		errors = append(errors, result.Errors...)
@@ -226,6 +431,8 @@ func getEKSClusterConfig(client EKSClusterAPI, clusterName, region string, ch ch
 			Server:                   *description.Cluster.Endpoint,
 			CertificateAuthorityData: decodedCertData,
 			Arn:                      *description.Cluster.Arn,
+			Version:                  aws.ToString(description.Cluster.Version),
+			Status:                   string(description.Cluster.Status),
 		},
 		Error: err,
 	}
@@ -265,7 +472,7 @@ func listEKSClusters(client EKSClusterAPI, region string) ([]string, error) {
 
 func generateIAMAuthenticatorExecConfig(cluster EKSClusterConfig, profile string) *v1.ExecConfig {
 	return &v1.ExecConfig{
-		Command: "aws-iam-authenticator",
+		Command: AuthenticatorCommand,
 		Args:    []string{"token", "-i", cluster.Name, "--region", cluster.Region},
 		Env: []v1.ExecEnvVar{
 			{
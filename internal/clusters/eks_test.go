@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/eks"
 	"github.com/aws/aws-sdk-go-v2/service/eks/types"
@@ -194,3 +195,99 @@ func TestEKSClusterScan(t *testing.T) {
 		}
 	}
 }
+
+func TestEKSContextNames(t *testing.T) {
+	t.Parallel()
+
+	account := clusters.EKSAccount{
+		Name:       "Prod",
+		Format:     "prod.${region}.${clusterName}",
+		ExtraUsers: []clusters.EKSUser{{Name: "admin", Profile: "prod-admin"}},
+	}
+
+	names := account.ContextNames(clusters.EKSClusterConfig{Name: "foo", Region: east1})
+
+	expected := []string{"prod.us-east-1.foo", "prod.us-east-1.foo.admin"}
+	if len(names) != len(expected) {
+		t.Fatalf("ContextNames() returned %v, but expected %v", names, expected)
+	}
+
+	for idx := range expected {
+		if names[idx] != expected[idx] {
+			t.Errorf("ContextNames()[%d] = %s, but expected %s", idx, names[idx], expected[idx])
+		}
+	}
+}
+
+func TestEKSRegionalProfiles(t *testing.T) {
+	t.Parallel()
+
+	account := clusters.EKSAccount{
+		Name:       "Prod",
+		Profile:    "prod-${region}",
+		Regions:    []string{east1, west2},
+		ExtraUsers: []clusters.EKSUser{{Name: "admin", Profile: "prod-admin"}},
+	}
+
+	if profile := account.ProfileFor(west2); profile != "prod-us-west-2" {
+		t.Errorf("ProfileFor() = %s, but expected prod-us-west-2", profile)
+	}
+
+	profiles := account.ListProfiles()
+
+	expected := []string{"prod-us-east-1", "prod-us-west-2", "prod-admin"}
+	if fmt.Sprint(profiles) != fmt.Sprint(expected) {
+		t.Errorf("ListProfiles() = %v, but expected %v", profiles, expected)
+	}
+}
+
+func TestEKSParseEntryName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		format   string
+		name     string
+		ok       bool
+		expected kubecfg.Metadata
+	}{
+		{
+			format:   "",
+			name:     "Prod.us-east-1.payments.v2",
+			ok:       true,
+			expected: kubecfg.Metadata{Account: "Prod", Region: east1, ClusterName: "payments.v2"},
+		},
+		{
+			format:   "",
+			name:     "Prod.us-west-2.payments.admin",
+			ok:       true,
+			expected: kubecfg.Metadata{Account: "Prod", Region: west2, ClusterName: "payments", ExtraUser: "admin"},
+		},
+		{
+			format:   "${clusterArn}",
+			name:     "arn:aws:eks:us-east-2:123456789012:cluster/payments",
+			ok:       true,
+			expected: kubecfg.Metadata{
+				Account: "Prod", Region: east2, ClusterName: "payments",
+				ClusterArn: "arn:aws:eks:us-east-2:123456789012:cluster/payments",
+			},
+		},
+		{
+			format: "",
+			name:   "Dev.us-east-1.payments",
+			ok:     false,
+		},
+	}
+
+	for _, test := range tests {
+		account := clusters.EKSAccount{
+			Name:       "Prod",
+			Format:     test.format,
+			ExtraUsers: []clusters.EKSUser{{Name: "admin", Profile: "prod-admin"}},
+		}
+
+		metadata, ok := account.ParseEntryName(test.name)
+		if ok != test.ok || metadata != test.expected {
+			t.Errorf("ParseEntryName(%s) = %+v, %t, but expected %+v, %t", test.name, metadata, ok, test.expected, test.ok)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package clusters
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aws/smithy-go"
+)
+
+// The error codes AWS returns when the credentials of a request are expired or invalid.
+//
+//nolint:gochecknoglobals
+var credentialsErrorCodes = map[string]struct{}{
+	"ExpiredToken":                {},
+	"ExpiredTokenException":       {},
+	"InvalidClientTokenId":        {},
+	"UnrecognizedClientException": {},
+	"InvalidSignatureException":   {},
+}
+
+// IsCredentialsError reports whether err was caused by credentials that are missing, expired or invalid, which retrying
+// won't fix until the user logs in again.
+func IsCredentialsError(err error) bool {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		if _, ok := credentialsErrorCodes[apiErr.ErrorCode()]; ok {
+			return true
+		}
+	}
+
+	// The SDK doesn't return a typed error when credentials can't be resolved, only a wrapped message
+	message := err.Error()
+
+	return strings.Contains(message, "get identity:") || strings.Contains(message, "failed to refresh cached credentials")
+}
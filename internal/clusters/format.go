@@ -1,11 +1,19 @@
 package clusters
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
+
+// FormatVariables are the variables that can be used within the format of an account.
+//
+//nolint:gochecknoglobals
+var FormatVariables = []string{"${name}", "${region}", "${clusterName}", "${clusterArn}"}
 
 func formatName(format string, replacements map[string]string) string {
 	// Use default format if an empty format was passed
 	if format == "" {
-		format = defaultFormat
+		format = DefaultFormat
 	}
 
 	for old, replacement := range replacements {
@@ -14,3 +22,63 @@ func formatName(format string, replacements map[string]string) string {
 
 	return format
 }
+
+// The patterns matching each format variable when parsing a generated name. Cluster names and ARNs can contain dots, so
+// they are matched lazily and rely on the literal text around them.
+//
+//nolint:gochecknoglobals
+var formatVariablePatterns = map[string]string{
+	"${region}":      `(?P<region>[a-z]{2}(?:-[a-z]+)+-\d+)`,
+	"${clusterName}": `(?P<clusterName>.+?)`,
+	"${clusterArn}":  `(?P<clusterArn>arn:.+?)`,
+}
+
+// parseName reverses formatName, returning the values of the variables within name. The values of fixed variables,
+// such as the account name, have to be passed in replacements.
+func parseName(format, name string, replacements map[string]string, suffixes []string) (map[string]string, bool) {
+	if format == "" {
+		format = DefaultFormat
+	}
+
+	pattern := regexp.QuoteMeta(format)
+
+	for variable, replacement := range replacements {
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta(variable), regexp.QuoteMeta(replacement))
+	}
+
+	for variable, variablePattern := range formatVariablePatterns {
+		// Only the first use of a variable can be a named group
+		quoted := regexp.QuoteMeta(variable)
+		pattern = strings.Replace(pattern, quoted, variablePattern, 1)
+		pattern = strings.ReplaceAll(pattern, quoted, `.+?`)
+	}
+
+	if len(suffixes) > 0 {
+		quotedSuffixes := make([]string, 0, len(suffixes))
+		for _, suffix := range suffixes {
+			quotedSuffixes = append(quotedSuffixes, regexp.QuoteMeta(suffix))
+		}
+
+		pattern += `(?:\.(?P<suffix>` + strings.Join(quotedSuffixes, "|") + `))?`
+	}
+
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return nil, false
+	}
+
+	match := re.FindStringSubmatch(name)
+	if match == nil {
+		return nil, false
+	}
+
+	values := make(map[string]string)
+
+	for idx, group := range re.SubexpNames() {
+		if group != "" && match[idx] != "" {
+			values[group] = match[idx]
+		}
+	}
+
+	return values, true
+}
@@ -0,0 +1,358 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var errInvalidExtraUser = errors.New("extra users must be written as name=profile")
+
+//nolint:gochecknoglobals
+var accountCommand = &cobra.Command{
+	Use:   "account",
+	Short: "manages the accounts within the .gogok8s.yaml file",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("error validating config: %w", err)
+		}
+
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var accountListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "lists the configured accounts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listAccounts()
+
+		return nil
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var accountShowCommand = &cobra.Command{
+	Use:               "show <name>",
+	Short:             "prints the config of an account",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountName,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		account, err := cfg.GetAccount(args[0])
+		if err != nil {
+			return err
+		}
+
+		return terminal.WriteStructured(terminal.OutputYAML, account)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var accountEditCommand = &cobra.Command{
+	Use:               "edit <name>",
+	Short:             "edits an account, prompting with its current values",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountName,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editAccount(args[0])
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var accountRemoveCommand = &cobra.Command{
+	Use:               "remove <name>",
+	Short:             "removes an account, optionally removing its kubeconfig entries as well",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAccountName,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		purge, _ := cmd.Flags().GetBool("purge")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		return removeAccount(args[0], purge, yes)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var accountRenameCommand = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "renames an account, renaming its kubeconfig entries to match",
+	Args:  cobra.ExactArgs(2),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return completeAccountName(cmd, args, toComplete)
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return renameAccount(args[0], args[1])
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func completeAccountName(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cfg == nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return cfg.ListAccountNamesFiltered(nil), cobra.ShellCompDirectiveNoFileComp
+}
+
+func listAccounts() {
+	accounts := cfg.ListAccounts()
+	if len(accounts) == 0 {
+		terminal.TextYellow("No accounts configured, run `gogok8s configure` to add one")
+
+		return
+	}
+
+	rows := make([][]string, 0, len(accounts))
+	for _, account := range accounts {
+		format := account.Format
+		if format == "" {
+			format = clusters.DefaultFormat
+		}
+
+		rows = append(rows, []string{
+			account.Name, account.Profile, strings.Join(account.Regions, ", "), format,
+			formatExtraUsers(account.ExtraUsers),
+		})
+	}
+
+	terminal.PrintTable([]string{"NAME", "PROFILE", "REGIONS", "FORMAT", "EXTRA USERS"}, rows)
+}
+
+func editAccount(name string) error {
+	account, err := cfg.GetAccount(name)
+	if err != nil {
+		return err
+	}
+
+	account, err = promptAccount(account)
+	if err != nil {
+		return err
+	}
+
+	if err = cfg.UpdateAccount(account); err != nil {
+		return err
+	}
+
+	if err = cfg.Write(); err != nil {
+		return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Account %s updated, run `gogok8s sync %s` to apply the changes", name, name))
+
+	return nil
+}
+
+// promptAccount prompts for every setting of an account apart from its name, using the current values as defaults.
+func promptAccount(account clusters.EKSAccount) (clusters.EKSAccount, error) {
+	profile, err := terminal.PromptDefault("AWS Profile", account.Profile)
+	if err != nil {
+		return account, fmt.Errorf("failed to select AWS profile: %w", err)
+	}
+
+	regions, err := terminal.MultiSelectDefault("AWS regions", config.ValidRegions, account.Regions)
+	if err != nil {
+		return account, fmt.Errorf("failed to select AWS regions: %w", err)
+	}
+
+	format := account.Format
+	if format == "" {
+		format = clusters.DefaultFormat
+	}
+
+	format, err = terminal.PromptDefault("Name format", format)
+	if err != nil {
+		return account, fmt.Errorf("failed to select name format: %w", err)
+	}
+
+	extraUsersValue, err := terminal.PromptWithValidate("Extra users (name=profile, comma separated)",
+		formatExtraUsers(account.ExtraUsers), func(s string) error {
+			_, err := parseExtraUsers(s)
+
+			return err
+		})
+	if err != nil {
+		return account, fmt.Errorf("failed to select extra users: %w", err)
+	}
+
+	extraUsers, _ := parseExtraUsers(extraUsersValue)
+
+	account.Profile = profile
+	account.Regions = regions
+	account.Format = format
+	account.ExtraUsers = extraUsers
+
+	return account, nil
+}
+
+func formatExtraUsers(users []clusters.EKSUser) string {
+	values := make([]string, 0, len(users))
+	for _, user := range users {
+		values = append(values, user.Name+"="+user.Profile)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func parseExtraUsers(value string) ([]clusters.EKSUser, error) {
+	var users []clusters.EKSUser
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, profile, ok := strings.Cut(entry, "=")
+		name, profile = strings.TrimSpace(name), strings.TrimSpace(profile)
+
+		if !ok || name == "" || profile == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidExtraUser, entry)
+		}
+
+		users = append(users, clusters.EKSUser{Name: name, Profile: profile})
+	}
+
+	return users, nil
+}
+
+func removeAccount(name string, purge, yes bool) error {
+	if _, err := cfg.GetAccount(name); err != nil {
+		return err
+	}
+
+	if !yes {
+		confirmed, err := terminal.Confirm(fmt.Sprintf("Remove account %s", name))
+		if err != nil {
+			return fmt.Errorf("failed to confirm removal: %w", err)
+		}
+
+		if !confirmed {
+			terminal.TextYellow("Removal cancelled")
+
+			return nil
+		}
+	}
+
+	if err := cfg.RemoveAccount(name); err != nil {
+		return err
+	}
+
+	if err := cfg.Write(); err != nil {
+		return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Account %s removed", name))
+
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return fmt.Errorf("error reading from kubeconfig: %w", err)
+	}
+
+	diff := kubecfg.RemoveAccount(kubeconfig, name)
+	if diff.Empty() {
+		return nil
+	}
+
+	terminal.TextYellow(fmt.Sprintf("\nkubeconfig entries generated for %s", name))
+	terminal.PrintDiff(diff)
+
+	if !purge {
+		if yes {
+			terminal.TextYellow("Keeping the kubeconfig entries, pass --purge to remove them")
+
+			return nil
+		}
+
+		purge, err = terminal.Confirm("Remove these kubeconfig entries")
+		if err != nil {
+			return fmt.Errorf("failed to confirm purge: %w", err)
+		}
+
+		if !purge {
+			return nil
+		}
+	}
+
+	err = kubecfg.Update(cfg.BackupRetention(), func(kubeconfig *api.Config) error {
+		kubecfg.RemoveAccount(kubeconfig, name)
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update kubeconfig: %w", err)
+	}
+
+	terminal.TextSuccess("kubeconfig entries removed")
+
+	return nil
+}
+
+func renameAccount(oldName, newName string) error {
+	if err := cfg.RenameAccount(oldName, newName); err != nil {
+		return err
+	}
+
+	account, _ := cfg.GetAccount(newName)
+
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return fmt.Errorf("error reading from kubeconfig: %w", err)
+	}
+
+	// Rename the kubeconfig entries first, so a collision leaves both the config and the kubeconfig untouched
+	diff, err := kubecfg.RenameAccount(kubeconfig, oldName, newName, account.EntryName)
+	if err != nil {
+		return fmt.Errorf("failed to rename kubeconfig entries: %w", err)
+	}
+
+	if !diff.Empty() {
+		err = kubecfg.Update(cfg.BackupRetention(), func(kubeconfig *api.Config) error {
+			diff, err = kubecfg.RenameAccount(kubeconfig, oldName, newName, account.EntryName)
+
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rename kubeconfig entries: %w", err)
+		}
+
+		terminal.PrintDiff(diff)
+	}
+
+	if err = cfg.Write(); err != nil {
+		return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Account %s renamed to %s", oldName, newName))
+
+	return nil
+}
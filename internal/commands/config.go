@@ -0,0 +1,374 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/source"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+const sourceFetchTimeout = 30 * time.Second
+
+var (
+	errNoConfigSource = errors.New("the config has no source, pass the URL or <git-repo>//<path> to pull from")
+	errConfigProblems = errors.New("config has problems")
+)
+
+//nolint:gochecknoglobals
+var configFileCommand = &cobra.Command{
+	Use:           "config",
+	Short:         "manages the .gogok8s.yaml file itself",
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var configMigrateCommand = &cobra.Command{
+	Use:   "migrate",
+	Short: "rewrites the config file in the layout of the current apiVersion, keeping a backup of the original",
+	Long: `Rewrites the config file in the layout of the current apiVersion. Older config files are already migrated in
+memory whenever they are read, so this only saves having to migrate them on every run. A copy of the original file is
+written next to it first. Fields gogok8s doesn't recognise are listed, since they are dropped from the rewritten file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// The file is migrated on its own, so it can be migrated even when merging it with its includes fails
+		if cfg == nil && cfgErr == nil {
+			return errConfigNotExist
+		} else if cfgErr != nil {
+			terminal.PrintError(cfgErr.Error())
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		return migrateConfigFile(viper.ConfigFileUsed(), dryRun)
+	},
+	Annotations:   map[string]string{annotationConfig: configOptional},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var configViewCommand = &cobra.Command{
+	Use:   "view",
+	Short: "prints the config file",
+	Long: `Prints the config file. With --merged, prints the effective config instead, after merging the files it includes
+and the ` + config.LocalFilename + ` of the current directory, preceded by the files that were merged.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		merged, _ := cmd.Flags().GetBool("merged")
+
+		return viewConfigFile(viper.ConfigFileUsed(), merged)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var configPullCommand = &cobra.Command{
+	Use:   "pull [url or git-repo//path]",
+	Short: "fetches the source of the config, showing what changed before pinning it",
+	Long: `Fetches the config fragment the source of the config points at, e.g. an account list maintained by a platform
+team, and shows how it differs from the contents accepted last. Once accepted, the fragment is cached and the source is
+pinned to its SHA-256, and it's merged beneath the config file until it's pulled again.
+
+The source is either an https URL or a file committed to a local git checkout, written as <repo>//<path>, optionally
+followed by ?ref=<ref>. Passing a source replaces the one in the config.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		location := ""
+		if len(args) == 1 {
+			location = args[0]
+		} else if cfg.Source != nil {
+			location = cfg.Source.URL
+		}
+
+		if location == "" {
+			return errNoConfigSource
+		}
+
+		cache, err := source.DefaultCache()
+		if err != nil {
+			return err
+		}
+
+		return pullConfigSource(cache, location, yes)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// pullConfigSource fetches the fragment at location and, once the changes since the last accepted contents are
+// confirmed, caches it and pins the source of the config to it.
+func pullConfigSource(cache *source.Cache, location string, yes bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sourceFetchTimeout)
+	defer cancel()
+
+	data, err := source.Fetch(ctx, http.DefaultClient, location)
+	if err != nil {
+		return err
+	}
+
+	// Reject fragments that can't be read before they're ever cached
+	fragment, err := config.Parse(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", location, err)
+	}
+
+	for _, field := range fragment.UnknownFields {
+		terminal.PrintWarning(fmt.Sprintf("unknown field %s in %s is ignored", field, location))
+	}
+
+	checksum := source.Checksum(data)
+
+	// The diff is against whatever was accepted last, which is the cache of the current source even when pulling from
+	// a new location
+	var previous []byte
+
+	if current := cfg.Source; current != nil {
+		previous, err = cache.Read(current.URL)
+		if err != nil && !errors.Is(err, source.ErrNotCached) {
+			return err
+		}
+
+		if current.URL == location && current.SHA256 == checksum && source.Checksum(previous) == checksum {
+			terminal.TextSuccess(fmt.Sprintf("%s is up to date at sha256 %s", location, checksum))
+
+			return nil
+		}
+	}
+
+	terminal.TextYellow(fmt.Sprintf("\nChanges from %s", location))
+	terminal.PrintLineDiff(source.Diff(previous, data))
+
+	if !yes {
+		confirmed, err := terminal.Confirm("Accept source")
+		if err != nil {
+			return fmt.Errorf("failed to confirm source: %w", err)
+		}
+
+		if !confirmed {
+			terminal.TextYellow("Pull cancelled")
+
+			return nil
+		}
+	}
+
+	if err = cache.Write(location, data); err != nil {
+		return err
+	}
+
+	cfg.SetSource(&config.SourceConfig{URL: location, SHA256: checksum})
+
+	if err = cfg.Write(); err != nil {
+		return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Pinned %s at sha256 %s", location, checksum))
+
+	return nil
+}
+
+//nolint:gochecknoglobals
+var configValidateCommand = &cobra.Command{
+	Use:   "validate [file]",
+	Short: "lists every problem of the config file, along with where it is",
+	Long: `Lists every problem of the config file, or of the file passed, along with the file, line and column of the
+setting it concerns. The files it includes, its source and the ` + config.LocalFilename + ` of the current directory
+are merged and checked as well, like whenever the config is read.
+
+Errors stop gogok8s from using the config, while warnings, such as unknown fields or empty profiles, are likely
+mistakes. Exits with an error when there are any errors, or any warnings with --strict.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		strict, _ := cmd.Flags().GetBool("strict")
+		output, _ := cmd.Flags().GetString("output")
+
+		format, err := terminal.ParseOutputFormat(output, terminal.OutputJSON, terminal.OutputYAML)
+		if err != nil {
+			return err
+		}
+
+		filename := viper.ConfigFileUsed()
+		if len(args) == 1 {
+			filename = args[0]
+		} else if filename == "" {
+			return errConfigNotExist
+		}
+
+		return validateConfigFile(filename, strict, format)
+	},
+	Annotations:   map[string]string{annotationConfig: configOwn, annotationStdout: stdoutWithOutputFormat},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var configSchemaCommand = &cobra.Command{
+	Use:   "schema",
+	Short: "prints a JSON Schema of the config file, for editors to validate and complete it",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return terminal.WriteStructured(terminal.OutputJSON, config.Schema())
+	},
+	Annotations:   map[string]string{annotationConfig: configSkipped},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// validationProblem is the machine-readable form of a config.Problem, written when --output is used.
+type validationProblem struct {
+	Severity config.Severity `json:"severity" yaml:"severity"`
+	File     string          `json:"file,omitempty" yaml:"file,omitempty"`
+	Line     int             `json:"line,omitempty" yaml:"line,omitempty"`
+	Column   int             `json:"column,omitempty" yaml:"column,omitempty"`
+	Path     string          `json:"path" yaml:"path"`
+	Message  string          `json:"message" yaml:"message"`
+}
+
+func validateConfigFile(filename string, strict bool, format terminal.OutputFormat) error {
+	file, err := config.Load(filename)
+	if err != nil {
+		return err
+	}
+
+	problems := file.Problems()
+
+	var errorCount, warningCount int
+
+	report := make([]validationProblem, 0, len(problems))
+
+	for _, problem := range problems {
+		if problem.Severity == config.SeverityError {
+			errorCount++
+		} else {
+			warningCount++
+		}
+
+		report = append(report, validationProblem{
+			Severity: problem.Severity,
+			File:     problem.File,
+			Line:     problem.Line,
+			Column:   problem.Column,
+			Path:     problem.Path,
+			Message:  problem.Err.Error(),
+		})
+	}
+
+	if format != terminal.OutputText {
+		if err = terminal.WriteStructured(format, report); err != nil {
+			return err
+		}
+	} else {
+		for _, warning := range file.Warnings {
+			terminal.PrintWarning(warning)
+		}
+
+		for _, problem := range problems {
+			if problem.Severity == config.SeverityError {
+				terminal.PrintError(problem.Error())
+			} else {
+				terminal.PrintWarning(problem.Error())
+			}
+		}
+	}
+
+	if errorCount > 0 || (strict && warningCount > 0) {
+		return fmt.Errorf("%w: %d errors, %d warnings", errConfigProblems, errorCount, warningCount)
+	}
+
+	if format == terminal.OutputText {
+		terminal.TextSuccess(fmt.Sprintf("%s is valid, with %d warnings", filename, warningCount))
+	}
+
+	return nil
+}
+
+func viewConfigFile(filename string, merged bool) error {
+	load := config.LoadFile
+	if merged {
+		load = config.Load
+	}
+
+	file, err := load(filename)
+	if err != nil {
+		return err
+	}
+
+	data, err := file.Config.Marshal()
+	if err != nil {
+		return err
+	}
+
+	if merged {
+		_, _ = fmt.Fprintln(os.Stdout, "# Merged from, in order of precedence:")
+		for _, layer := range file.Layers {
+			_, _ = fmt.Fprintf(os.Stdout, "#   %s\n", layer.Filename)
+		}
+	}
+
+	_, _ = os.Stdout.Write(data)
+
+	return nil
+}
+
+func migrateConfigFile(filename string, dryRun bool) error {
+	file, err := config.LoadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if !file.NeedsMigration() {
+		terminal.TextSuccess(fmt.Sprintf("%s is already at apiVersion %s", filename, config.CurrentAPIVersion))
+
+		return nil
+	}
+
+	if dryRun {
+		data, err := file.Config.Marshal()
+		if err != nil {
+			return err
+		}
+
+		_, _ = os.Stdout.Write(data)
+
+		return nil
+	}
+
+	backup, err := config.BackupFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if err = file.Config.WriteToFile(filename); err != nil {
+		return fmt.Errorf("failed to write %s config: %w", filename, err)
+	}
+
+	from := file.APIVersion
+	if from == "" {
+		from = "(none)"
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Migrated %s from apiVersion %s to %s, the original was saved to %s", filename, from,
+		config.CurrentAPIVersion, backup))
+
+	return nil
+}
@@ -1,27 +1,41 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/BigPapaChas/gogok8s/internal/clusters"
 	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/discover"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
 	"github.com/BigPapaChas/gogok8s/internal/terminal"
 )
 
+var (
+	errMissingConfigureFlags  = errors.New("stdin is not a terminal, so the following flags are required")
+	errDiscoverNotInteractive = errors.New("stdin is not a terminal, --discover needs to prompt for the accounts to add")
+)
+
 //nolint:gochecknoglobals
 var configCmd = &cobra.Command{
 	Use:           "configure",
 	Short:         "create a new account entry within the .gogok8s.yaml file",
+	Annotations:   map[string]string{annotationConfig: configOptional},
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if debug {
-			terminal.EnableDebug()
+		// Adding an account can fix the config file, so it is used on its own when merging it with its includes fails
+		if cfgErr != nil {
+			terminal.PrintError(cfgErr.Error())
+
+			cfg = loadOwnConfig(viper.ConfigFileUsed())
 		}
 
 		if cfg == nil {
@@ -35,64 +49,278 @@ var configCmd = &cobra.Command{
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var filename string
-		if cfg == nil {
-			// An existing gogok8s config file was not found, prompt user for filename to use
-			home, err := os.UserHomeDir()
+		if discovering, _ := cmd.Flags().GetBool("discover"); discovering {
+			return discoverAccounts()
+		}
+
+		name, _ := cmd.Flags().GetString("name")
+		profile, _ := cmd.Flags().GetString("profile")
+		regions, _ := cmd.Flags().GetStringSlice("region")
+		format, _ := cmd.Flags().GetString("format")
+		extraUsers, _ := cmd.Flags().GetStringArray("extra-user")
+
+		account := clusters.EKSAccount{
+			Name:    name,
+			Profile: profile,
+			Regions: regions,
+			Format:  format,
+		}
+
+		for _, value := range extraUsers {
+			users, err := parseExtraUsers(value)
 			if err != nil {
-				return fmt.Errorf("failed to find user home directory: %w", err)
+				return err
 			}
 
-			var defaultFilename string
-			if cfgFile == "" {
-				defaultFilename = path.Join(home, ".gogok8s.yaml")
-			} else {
-				defaultFilename = cfgFile
+			account.ExtraUsers = append(account.ExtraUsers, users...)
+		}
+
+		// Prompts are only shown for the values that weren't passed as flags
+		interactive := name == "" || profile == "" || len(regions) == 0
+		if interactive && !terminal.IsInteractive() {
+			if err := missingConfigureFlagsError(account); err != nil {
+				return err
 			}
-			filename, err = terminal.PromptDefault("Gogok8s config file", defaultFilename)
-			if err != nil {
-				return fmt.Errorf("failed to get gogok8s config file: %w", err)
+
+			// Only --region was left out, and the account inherits the default regions instead
+			interactive = false
+		}
+
+		return configureAccount(account, interactive)
+	},
+}
+
+// missingConfigureFlagsError returns an error listing the flags required to configure the account without prompts, or
+// nil when none are missing.
+func missingConfigureFlagsError(account clusters.EKSAccount) error {
+	var missing []string
+
+	if account.Name == "" {
+		missing = append(missing, "--name")
+	}
+
+	if account.Profile == "" {
+		missing = append(missing, "--profile")
+	}
+
+	if len(account.Regions) == 0 && (cfg == nil || len(cfg.Defaults.Regions) == 0) {
+		missing = append(missing, "--region")
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errMissingConfigureFlags, strings.Join(missing, ", "))
+}
+
+func configureAccount(account clusters.EKSAccount, interactive bool) error {
+	filename, err := prepareConfig(interactive)
+	if err != nil {
+		return err
+	}
+
+	if err := promptMissingAccountValues(&account, interactive); err != nil {
+		return err
+	}
+
+	if err := cfg.IsValidAccountName(account.Name); err != nil {
+		return err
+	}
+
+	cfg.AddAccount(account)
+
+	if err := writeConfiguredConfig(filename); err != nil {
+		return err
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Account %s configured", account.Name))
+
+	return nil
+}
+
+// discoverAccounts proposes accounts for the AWS profiles and the EKS users of the kubeconfig that aren't configured
+// yet, and adds the ones selected.
+func discoverAccounts() error {
+	if !terminal.IsInteractive() {
+		return errDiscoverNotInteractive
+	}
+
+	profiles, err := discover.LoadAWSProfiles()
+	if err != nil {
+		return err
+	}
+
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return err
+	}
+
+	var (
+		labels   []string
+		defaults []string
+		accounts = make(map[string]clusters.EKSAccount)
+	)
+
+	for _, proposal := range discover.Propose(profiles, discover.KubeConfigRegions(kubeconfig)) {
+		account, ok := newDiscoveredAccount(proposal.Account)
+		if !ok {
+			continue
+		}
+
+		label := fmt.Sprintf("%s (%s)", account.Name, strings.Join(account.Regions, ", "))
+		labels = append(labels, label)
+		accounts[label] = account
+
+		// Profiles already used by the kubeconfig are pre-selected
+		if proposal.InKubeConfig {
+			defaults = append(defaults, label)
+		}
+	}
+
+	if len(labels) == 0 {
+		terminal.TextSuccess("No new accounts were discovered")
+
+		return nil
+	}
+
+	selected, err := terminal.MultiSelectDefault("Accounts to add", labels, defaults)
+	if err != nil {
+		return fmt.Errorf("failed to select accounts: %w", err)
+	}
+
+	if len(selected) == 0 {
+		return nil
+	}
+
+	filename, err := prepareConfig(true)
+	if err != nil {
+		return err
+	}
+
+	for _, label := range selected {
+		cfg.AddAccount(accounts[label])
+	}
+
+	if err := writeConfiguredConfig(filename); err != nil {
+		return err
+	}
+
+	for _, label := range selected {
+		terminal.TextSuccess(fmt.Sprintf("Account %s configured", accounts[label].Name))
+	}
+
+	return nil
+}
+
+// newDiscoveredAccount returns the discovered account with the regions gogok8s doesn't support removed, or false when
+// it is already configured or has no regions left.
+func newDiscoveredAccount(account clusters.EKSAccount) (clusters.EKSAccount, bool) {
+	if cfg != nil {
+		for _, existing := range cfg.Accounts {
+			if existing.Name == account.Name || existing.Profile == account.Profile {
+				return account, false
 			}
+		}
+	}
+
+	var regions []string
 
-			cfg = config.NewConfig()
+	for _, region := range account.Regions {
+		if slices.Contains(config.ValidRegions, region) {
+			regions = append(regions, region)
 		}
+	}
+
+	account.Regions = regions
+
+	return account, len(regions) > 0
+}
+
+// prepareConfig creates an empty config when no config file was found, returning the filename it is going to be written
+// to. An empty filename is returned when an existing config is being modified.
+func prepareConfig(interactive bool) (string, error) {
+	if cfg != nil {
+		return "", nil
+	}
 
-		accountName, err := terminal.PromptWithValidate("Account name", "", cfg.IsValidAccountName)
+	// The config file exists but couldn't be read, creating a new one would overwrite it
+	if cfgErr != nil {
+		return "", fmt.Errorf("failed to read config: %w", cfgErr)
+	}
+
+	// An existing gogok8s config file was not found, prompt user for filename to use
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user home directory: %w", err)
+	}
+
+	filename := path.Join(home, ".gogok8s.yaml")
+	if cfgFile != "" {
+		filename = cfgFile
+	}
+
+	if interactive {
+		filename, err = terminal.PromptDefault("Gogok8s config file", filename)
 		if err != nil {
-			return fmt.Errorf("failed to select AWS account: %w", err)
+			return "", fmt.Errorf("failed to get gogok8s config file: %w", err)
 		}
+	}
 
-		profile, err := terminal.PromptDefault("AWS Profile", "")
-		if err != nil {
-			return fmt.Errorf("failed to select AWS profile: %w", err)
+	cfg = config.NewConfig()
+
+	return filename, nil
+}
+
+// writeConfiguredConfig validates the config and writes it to filename, or to the config file it was read from when
+// filename is empty.
+func writeConfiguredConfig(filename string) error {
+	// Regions passed as flags haven't been checked yet, so the whole config is validated before it is written
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("error validating config: %w", err)
+	}
+
+	if filename != "" {
+		// A new configuration file was created, write the updated config to the user-specified filename
+		terminal.PrintDebug(filename)
+		if err := cfg.WriteToFile(filename); err != nil {
+			return fmt.Errorf("failed to write %s config: %w", filename, err)
+		}
+	} else {
+		// An existing configuration is being modified, write to the location that was used when running the command
+		if err := cfg.Write(); err != nil {
+			return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
 		}
+	}
 
-		regions, err := terminal.MultiSelect("AWS regions", config.ValidRegions)
+	return nil
+}
+
+// promptMissingAccountValues prompts for the values of the account that weren't passed as flags, pre-selecting the
+// default regions.
+func promptMissingAccountValues(account *clusters.EKSAccount, interactive bool) error {
+	var err error
+
+	if account.Name == "" {
+		account.Name, err = terminal.PromptWithValidate("Account name", "", cfg.IsValidAccountName)
 		if err != nil {
-			return fmt.Errorf("failed to select AWS regions: %w", err)
+			return fmt.Errorf("failed to select AWS account: %w", err)
 		}
+	}
 
-		account := clusters.EKSAccount{
-			Profile: profile,
-			Regions: regions,
-			Name:    accountName,
+	if account.Profile == "" {
+		account.Profile, err = terminal.PromptDefault("AWS Profile", "")
+		if err != nil {
+			return fmt.Errorf("failed to select AWS profile: %w", err)
 		}
-		cfg.AddAccount(account)
+	}
 
-		if filename != "" {
-			// A new configuration file was created, write the updated config to the user-specified filename
-			terminal.PrintDebug(filename)
-			if err := cfg.WriteToFile(filename); err != nil {
-				return fmt.Errorf("failed to write %s config: %w", filename, err)
-			}
-		} else {
-			// An existing configuration is being modified, write to the location that was used when running the command
-			if err := cfg.Write(); err != nil {
-				return fmt.Errorf("failed to write %s config: %w", viper.ConfigFileUsed(), err)
-			}
+	if len(account.Regions) == 0 && interactive {
+		account.Regions, err = terminal.MultiSelectDefault("AWS regions", config.ValidRegions, cfg.Defaults.Regions)
+		if err != nil {
+			return fmt.Errorf("failed to select AWS regions: %w", err)
 		}
-		terminal.TextSuccess(fmt.Sprintf("Account %s configured", accountName))
+	}
 
-		return nil
-	},
+	return nil
 }
@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/doctor"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var errDoctorChecksFailed = errors.New("doctor found failing checks")
+
+//nolint:gochecknoglobals
+var doctorCommand = &cobra.Command{
+	Use:   "doctor",
+	Short: "checks your config, AWS credentials and kubeconfig for common problems",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDoctor()
+	},
+	Annotations:   map[string]string{annotationConfig: configOptional},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func runDoctor() error {
+	configFile, err := getConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	kubeconfigFile, err := kubecfg.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("error finding kubeconfig: %w", err)
+	}
+
+	var accounts []clusters.EKSAccount
+	if cfg != nil {
+		accounts = cfg.ListAccounts()
+	}
+
+	results := doctor.New(accounts, configFile, kubeconfigFile).Run()
+
+	// The config is only validated once it has been read, so its result goes after the config file check. A config that
+	// couldn't be merged with its includes or source fails the check as well
+	if cfg != nil || cfgErr != nil {
+		validation := doctor.Result{Check: "config", Status: doctor.StatusPass, Message: "valid"}
+
+		err = cfgErr
+		if err == nil {
+			err = cfg.Validate()
+		}
+
+		if err != nil {
+			validation.Status = doctor.StatusFail
+			validation.Message = err.Error()
+			validation.Hint = "fix the config with `gogok8s configure` or by editing " + configFile
+		}
+
+		results = append(results[:1], append([]doctor.Result{validation}, results[1:]...)...)
+	}
+
+	var failed int
+
+	for _, result := range results {
+		message := fmt.Sprintf("%s: %s", result.Check, result.Message)
+
+		switch result.Status {
+		case doctor.StatusPass:
+			terminal.TextSuccess(message)
+		case doctor.StatusWarn:
+			terminal.TextWarning(message)
+		case doctor.StatusFail:
+			failed++
+
+			terminal.TextError(message)
+		}
+
+		if result.Hint != "" {
+			terminal.TextHint(result.Hint)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d checks failed", errDoctorChecksFailed, failed, len(results))
+	}
+
+	return nil
+}
+
+// getConfigFilePath returns the config file that was read, falling back to where it would be read from.
+func getConfigFilePath() (string, error) {
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used, nil
+	}
+
+	if cfgFile != "" {
+		return cfgFile, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".gogok8s.yaml"), nil
+}
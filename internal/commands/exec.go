@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BigPapaChas/gogok8s/internal/fanout"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var (
+	errNoMatchingContexts = errors.New("no contexts managed by gogok8s matched the filters")
+	errExecFailed         = errors.New("command failed")
+)
+
+//nolint:gochecknoglobals
+var execCommand = &cobra.Command{
+	Use:   "exec [flags] -- <command> [args...]",
+	Short: "runs a command once for every gogok8s context matching the filters",
+	Long: `Runs a command once for every context managed by gogok8s that matches the filters, e.g.
+
+  gogok8s exec --account Prod --region 'us-*' --cluster 'api-*' -- kubectl get nodes
+
+Each run gets its own KUBECONFIG holding only its context, so any tool that reads the kubeconfig works. Pass
+--context-flag to pass --context <name> to the command instead. The filters are glob patterns, and each can be
+repeated. Every line of output is prefixed with the context it came from.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter := contextFilter{}
+		filter.Accounts, _ = cmd.Flags().GetStringSlice("account")
+		filter.Regions, _ = cmd.Flags().GetStringSlice("region")
+		filter.Clusters, _ = cmd.Flags().GetStringSlice("cluster")
+		filter.Users, _ = cmd.Flags().GetStringSlice("user")
+		parallel, _ := cmd.Flags().GetInt("parallel")
+		contextFlag, _ := cmd.Flags().GetBool("context-flag")
+
+		if err := filter.validate(); err != nil {
+			return err
+		}
+
+		return execInContexts(args, filter, parallel, contextFlag)
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// contextFilter matches managed contexts by the glob patterns of their metadata. An empty list of patterns matches
+// everything, apart from Users which only matches contexts without an extra user.
+type contextFilter struct {
+	Accounts []string
+	Regions  []string
+	Clusters []string
+	Users    []string
+}
+
+func (f contextFilter) validate() error {
+	for _, patterns := range [][]string{f.Accounts, f.Regions, f.Clusters, f.Users} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (f contextFilter) matches(metadata kubecfg.Metadata) bool {
+	if len(f.Users) == 0 && metadata.ExtraUser != "" {
+		return false
+	}
+
+	return matchesAny(f.Accounts, metadata.Account) &&
+		matchesAny(f.Regions, metadata.Region) &&
+		matchesAny(f.Clusters, metadata.ClusterName) &&
+		matchesAny(f.Users, metadata.ExtraUser)
+}
+
+func matchesAny(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func execInContexts(command []string, filter contextFilter, parallel int, contextFlag bool) error {
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return fmt.Errorf("error reading from kubeconfig: %w", err)
+	}
+
+	var contexts []kubecfg.ManagedContext
+
+	for _, managed := range kubecfg.ListManagedContexts(kubeconfig) {
+		if filter.matches(managed.Metadata) {
+			contexts = append(contexts, managed)
+		}
+	}
+
+	if len(contexts) == 0 {
+		return errNoMatchingContexts
+	}
+
+	dir, err := os.MkdirTemp("", "gogok8s-exec-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	jobs := make([]fanout.Job, 0, len(contexts))
+
+	for idx, managed := range contexts {
+		job := fanout.Job{
+			Name:    managed.Name,
+			Command: command,
+			Env: append(os.Environ(),
+				"GOGOK8S_CONTEXT="+managed.Name,
+				"GOGOK8S_ACCOUNT="+managed.Metadata.Account,
+				"GOGOK8S_REGION="+managed.Metadata.Region,
+				"GOGOK8S_CLUSTER="+managed.Metadata.ClusterName,
+			),
+		}
+
+		if contextFlag {
+			job.Command = append([]string{command[0], "--context", managed.Name}, command[1:]...)
+		} else {
+			scoped, err := kubecfg.ScopeToContext(kubeconfig, managed.Name)
+			if err != nil {
+				return err
+			}
+
+			filename := filepath.Join(dir, "config-"+strconv.Itoa(idx))
+			if err = kubecfg.WriteToFile(scoped, filename); err != nil {
+				return err
+			}
+
+			job.Env = append(job.Env, "KUBECONFIG="+filename)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	results := fanout.Run(ctx, jobs, parallel, os.Stdout, os.Stderr)
+
+	return printExecSummary(results)
+}
+
+func printExecSummary(results []fanout.Result) error {
+	rows := make([][]string, 0, len(results))
+	failed := 0
+
+	for _, result := range results {
+		status := "ok"
+		if !result.Succeeded() {
+			status = result.Err.Error()
+			failed++
+		}
+
+		rows = append(rows, []string{
+			result.Name, status, strconv.Itoa(result.ExitCode), result.Duration.Round(time.Millisecond).String(),
+		})
+	}
+
+	terminal.PrintTable([]string{"CONTEXT", "RESULT", "EXIT CODE", "DURATION"}, rows)
+
+	if failed > 0 {
+		return fmt.Errorf("%w in %d of %d contexts", errExecFailed, failed, len(results))
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Command succeeded in %d contexts", len(results)))
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var (
+	errNoClustersExported = errors.New("no clusters matched, nothing was exported")
+	errNoAccountsExported = errors.New("no accounts matched, nothing was exported")
+	errExportScanFailed   = errors.New("failed to scan accounts, nothing was exported")
+)
+
+//nolint:gochecknoglobals
+var exportCommand = &cobra.Command{
+	Use:   "export [accounts]",
+	Short: "writes a standalone kubeconfig for the clusters of your accounts, without modifying your kubeconfig",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("error validating config: %w", err)
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pattern, _ := cmd.Flags().GetString("cluster")
+		output, _ := cmd.Flags().GetString("output")
+		currentContext, _ := cmd.Flags().GetString("current-context")
+
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --cluster pattern: %w", err)
+		}
+
+		return exportKubeConfig(args, pattern, output, currentContext)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cfg != nil {
+			return cfg.ListAccountNamesFiltered(args), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Annotations:   map[string]string{annotationStdout: stdoutWithoutOutputFile},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func exportKubeConfig(accounts []string, pattern, output, currentContext string) error {
+	var eksAccounts []clusters.ClusterAccount
+	if len(accounts) == 0 {
+		eksAccounts = cfg.GetAccounts()
+	} else {
+		eksAccounts = cfg.ListAccountsFiltered(accounts)
+	}
+
+	for _, account := range eksAccounts {
+		if err := cfg.CheckAccountEnv(account.PrettyName()); err != nil {
+			return err
+		}
+	}
+
+	if len(eksAccounts) == 0 {
+		return errNoAccountsExported
+	}
+
+	patch, results := fetchKubeConfigFromAccounts(eksAccounts)
+
+	// A kubeconfig missing the clusters of an account that couldn't be scanned would pass for a complete one
+	var failed []string
+
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			failed = append(failed, result.AccountName)
+		}
+	}
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+
+		return fmt.Errorf("%w: %s", errExportScanFailed, strings.Join(failed, ", "))
+	}
+
+	if pattern != "" {
+		// Patterns match either the EKS cluster name or the generated kubeconfig name
+		patch = kubecfg.FilterPatch(patch, func(name string, metadata kubecfg.Metadata) bool {
+			nameMatched, _ := path.Match(pattern, name)
+			clusterMatched, _ := path.Match(pattern, metadata.ClusterName)
+
+			return nameMatched || clusterMatched
+		})
+	}
+
+	if len(patch.Clusters) == 0 {
+		return errNoClustersExported
+	}
+
+	kubeconfig, err := kubecfg.NewConfigFromPatch(patch, currentContext)
+	if err != nil {
+		return fmt.Errorf("error setting current-context: %w", err)
+	}
+
+	if output == "" {
+		content, err := clientcmd.Write(*kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to serialize kubeconfig: %w", err)
+		}
+
+		if _, err = os.Stdout.Write(content); err != nil {
+			return fmt.Errorf("failed to write kubeconfig: %w", err)
+		}
+
+		return nil
+	}
+
+	if err = kubecfg.WriteToFile(kubeconfig, output); err != nil {
+		return err
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("Exported %d clusters to %s", len(patch.Clusters), output))
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+//nolint:gochecknoglobals
+var listCommand = &cobra.Command{
+	Use:   "list [accounts]",
+	Short: "lists every cluster found within your accounts, without modifying your kubeconfig",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("error validating config: %w", err)
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cached, _ := cmd.Flags().GetBool("cached")
+		output, _ := cmd.Flags().GetString("output")
+
+		format, err := terminal.ParseOutputFormat(output, terminal.OutputJSON, terminal.OutputYAML, terminal.OutputCSV)
+		if err != nil {
+			return err
+		}
+
+		return listClusters(args, cached, format)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cfg != nil {
+			return cfg.ListAccountNamesFiltered(args), cobra.ShellCompDirectiveNoFileComp
+		}
+
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	Annotations:   map[string]string{annotationStdout: stdoutWithOutputFormat},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func listClusters(accounts []string, cached bool, format terminal.OutputFormat) error {
+	var eksAccounts []clusters.ClusterAccount
+	if len(accounts) == 0 {
+		eksAccounts = cfg.GetAccounts()
+	} else {
+		eksAccounts = cfg.ListAccountsFiltered(accounts)
+	}
+
+	if len(eksAccounts) == 0 {
+		// Scripts parsing the output still get an empty list, or just the header of the csv
+		if format != terminal.OutputText {
+			return printClusterInfos(nil, format)
+		}
+
+		return nil
+	}
+
+	cache, err := clusters.LoadClusterCache()
+	if err != nil {
+		return fmt.Errorf("error loading cluster cache: %w", err)
+	}
+
+	var infos []clusters.ClusterInfo
+
+	if cached {
+		for _, account := range eksAccounts {
+			cachedAccount, ok := cache.Accounts[account.PrettyName()]
+			if !ok {
+				terminal.PrintWarning(fmt.Sprintf("no cached clusters for account `%s`, run `gogok8s list` to scan it",
+					account.PrettyName()))
+
+				continue
+			}
+
+			terminal.PrintDebug(fmt.Sprintf("%s scanned at %s", account.PrettyName(), cachedAccount.ScannedAt))
+			infos = append(infos, cachedAccount.Clusters...)
+		}
+	} else {
+		infos = scanAccountsForClusters(eksAccounts, cache)
+
+		if err = cache.Save(); err != nil {
+			terminal.PrintWarning(err.Error())
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+
+		return a.Name < b.Name
+	})
+
+	return printClusterInfos(infos, format)
+}
+
+type listClustersResult struct {
+	Clusters    []clusters.ClusterInfo
+	Errors      []error
+	AccountName string
+}
+
+// scanAccountsForClusters scans every account concurrently, updating the cache for each account scanned without errors.
+func scanAccountsForClusters(accounts []clusters.ClusterAccount, cache *clusters.ClusterCache) []clusters.ClusterInfo {
+	spinner, _ := terminal.StartNewSpinner("Scanning accounts for Kubernetes clusters...")
+	ch := make(chan listClustersResult, len(accounts))
+
+	for _, account := range accounts {
+		go func(account clusters.ClusterAccount) {
+			infos, errors := account.ListClusters()
+
+			ch <- listClustersResult{
+				Clusters:    infos,
+				Errors:      errors,
+				AccountName: account.PrettyName(),
+			}
+		}(account)
+	}
+
+	var infos []clusters.ClusterInfo
+
+	for range accounts {
+		result := <-ch
+		infos = append(infos, result.Clusters...)
+
+		if len(result.Errors) > 0 {
+			terminal.TextWarning(result.AccountName)
+			terminal.PrintBulletedWarnings(result.Errors)
+
+			// Keep the previous results cached rather than replacing them with a partial scan
+			continue
+		}
+
+		cache.Update(result.AccountName, result.Clusters, time.Now())
+	}
+
+	_ = spinner.Stop()
+
+	return infos
+}
+
+func printClusterInfos(infos []clusters.ClusterInfo, format terminal.OutputFormat) error {
+	switch format {
+	case terminal.OutputJSON, terminal.OutputYAML:
+		if infos == nil {
+			infos = []clusters.ClusterInfo{}
+		}
+
+		return terminal.WriteStructured(format, infos)
+	case terminal.OutputCSV:
+		rows := make([][]string, 0, len(infos))
+		for _, info := range infos {
+			rows = append(rows, []string{
+				info.Account, info.Region, info.Name, info.Arn, info.Endpoint, info.Version, info.Status,
+				strings.Join(info.Contexts, " "),
+			})
+		}
+
+		return terminal.WriteCSV(
+			[]string{"account", "region", "name", "arn", "endpoint", "version", "status", "contexts"},
+			rows,
+		)
+	}
+
+	if len(infos) == 0 {
+		terminal.TextYellow("No clusters found")
+
+		return nil
+	}
+
+	rows := make([][]string, 0, len(infos))
+	for _, info := range infos {
+		rows = append(rows, []string{
+			info.Account, info.Region, info.Name, info.Version, info.Status, info.Endpoint, info.Arn,
+			strings.Join(info.Contexts, ", "),
+		})
+	}
+
+	terminal.PrintTable(
+		[]string{"ACCOUNT", "REGION", "NAME", "VERSION", "STATUS", "ENDPOINT", "ARN", "CONTEXTS"},
+		rows,
+	)
+
+	return nil
+}
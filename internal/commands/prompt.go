@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var errInvalidShell = errors.New("invalid shell, must be one of: bash, zsh, fish, plain")
+
+// Snippets that add the prompt segment to each shell, printed by `gogok8s prompt init`.
+//
+//nolint:gochecknoglobals
+var promptSnippets = map[string]string{
+	"bash": `# Add to ~/.bashrc
+__gogok8s_ps1() {
+  local segment
+  segment="$(gogok8s prompt --shell bash 2>/dev/null)"
+  [ -n "$segment" ] && printf '%s ' "$segment"
+}
+PS1='$(__gogok8s_ps1)'"$PS1"
+`,
+	"zsh": `# Add to ~/.zshrc
+setopt PROMPT_SUBST
+__gogok8s_prompt() {
+  local segment
+  segment="$(gogok8s prompt --shell zsh 2>/dev/null)"
+  [[ -n "$segment" ]] && print -n -- "$segment "
+}
+PROMPT='$(__gogok8s_prompt)'"$PROMPT"
+`,
+	"fish": `# Add to ~/.config/fish/config.fish
+functions -c fish_prompt __gogok8s_original_prompt
+function fish_prompt
+    set -l segment (gogok8s prompt --shell fish 2>/dev/null)
+    test -n "$segment"; and printf '%s ' $segment
+    __gogok8s_original_prompt
+end
+`,
+	"starship": `# Add to ~/.config/starship.toml
+[custom.gogok8s]
+command = "gogok8s prompt --no-color"
+when = "gogok8s prompt --no-color | grep -q ."
+format = "[$output]($style) "
+style = "bold cyan"
+`,
+}
+
+//nolint:gochecknoglobals
+var promptCommand = &cobra.Command{
+	Use:   "prompt",
+	Short: "prints the account, region and cluster of the current context, for use within a shell prompt",
+	Long: `Prints the account, region, cluster and extra user of the current context, for use within a shell prompt.
+Only the kubeconfig and config file are read, no calls are made to AWS. Nothing is printed when the current context
+wasn't generated by gogok8s. Use ` + "`gogok8s prompt init <shell>`" + ` for a snippet that adds it to your prompt.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shell, _ := cmd.Flags().GetString("shell")
+		noColor, _ := cmd.Flags().GetBool("no-color")
+
+		escaper, err := newPromptEscaper(shell)
+		if err != nil {
+			return err
+		}
+
+		segment := renderPrompt(escaper, noColor)
+		if segment != "" {
+			_, _ = fmt.Fprint(os.Stdout, segment)
+		}
+
+		return nil
+	},
+	Annotations:   map[string]string{annotationConfig: configQuiet},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var promptInitCommand = &cobra.Command{
+	Use:       "init <bash|zsh|fish|starship>",
+	Short:     "prints a snippet that adds the gogok8s prompt segment to your shell",
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"bash", "zsh", "fish", "starship"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, _ = fmt.Fprint(os.Stdout, promptSnippets[args[0]])
+
+		return nil
+	},
+	Annotations:   map[string]string{annotationConfig: configQuiet},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+// promptEscaper escapes the prompt segment for the shell it is shown in.
+type promptEscaper struct {
+	// text escapes characters the shell would otherwise expand.
+	text func(text string) string
+	// code wraps a color escape code, so the shell knows it takes up no space.
+	code func(code string) string
+}
+
+func newPromptEscaper(shell string) (promptEscaper, error) {
+	unchanged := func(s string) string { return s }
+
+	switch shell {
+	case "bash":
+		return promptEscaper{text: unchanged, code: func(code string) string { return "\x01" + code + "\x02" }}, nil
+	case "zsh":
+		return promptEscaper{
+			text: func(text string) string { return strings.ReplaceAll(text, "%", "%%") },
+			code: func(code string) string { return "%{" + code + "%}" },
+		}, nil
+	case "fish", "plain", "":
+		return promptEscaper{text: unchanged, code: unchanged}, nil
+	}
+
+	return promptEscaper{}, fmt.Errorf("%w: %s", errInvalidShell, shell)
+}
+
+// renderPrompt returns the prompt segment of the current context, or an empty string when the current context can't be
+// attributed to an account. Errors are only shown with --debug, since a prompt shouldn't print anything else.
+func renderPrompt(escaper promptEscaper, noColor bool) string {
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		terminal.PrintDebug(err.Error())
+
+		return ""
+	}
+
+	context, ok := kubeconfig.Contexts[kubeconfig.CurrentContext]
+	if !ok {
+		return ""
+	}
+
+	metadata, ok := kubecfg.GetMetadata(context.Extensions)
+	if !ok {
+		metadata, ok = parseContextName(kubeconfig.CurrentContext)
+	}
+
+	if !ok {
+		return ""
+	}
+
+	settings := config.NewConfig().PromptSettings()
+	production := false
+
+	if cfg != nil {
+		settings = cfg.PromptSettings()
+
+		if account, err := cfg.GetAccount(metadata.Account); err == nil {
+			production = account.Production
+		}
+	}
+
+	user := ""
+	if metadata.ExtraUser != "" {
+		user = "@" + metadata.ExtraUser
+	}
+
+	segment := escaper.text(strings.NewReplacer(
+		"${account}", metadata.Account,
+		"${region}", metadata.Region,
+		"${cluster}", metadata.ClusterName,
+		"${user}", user,
+		"${context}", kubeconfig.CurrentContext,
+		"${namespace}", context.Namespace,
+	).Replace(settings.Format))
+
+	if noColor {
+		return segment
+	}
+
+	color := settings.Color
+	if production {
+		color = settings.ProductionColor
+	}
+
+	return terminal.Colorize(segment, color, escaper.code)
+}
+
+// parseContextName falls back to the naming template of each account for contexts written without metadata.
+func parseContextName(name string) (kubecfg.Metadata, bool) {
+	if cfg == nil {
+		return kubecfg.Metadata{}, false
+	}
+
+	for _, account := range cfg.ListAccounts() {
+		if metadata, ok := account.ParseEntryName(name); ok {
+			return metadata, true
+		}
+	}
+
+	return kubecfg.Metadata{}, false
+}
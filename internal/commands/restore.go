@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var errNoBackupSelected = errors.New("a backup timestamp or --last must be provided, use --list to see available backups")
+
+//nolint:gochecknoglobals
+var restoreCommand = &cobra.Command{
+	Use:   "restore [--list | <timestamp> | --last]",
+	Short: "restores your kubeconfig from a backup taken by sync",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		list, _ := cmd.Flags().GetBool("list")
+		last, _ := cmd.Flags().GetBool("last")
+		yes, _ := cmd.Flags().GetBool("yes")
+
+		if list {
+			return listKubeConfigBackups()
+		}
+
+		var (
+			backup kubecfg.Backup
+			err    error
+		)
+
+		switch {
+		case len(args) == 1:
+			backup, err = kubecfg.FindBackup(args[0])
+		case last:
+			backup, err = kubecfg.LatestBackup()
+		default:
+			return errNoBackupSelected
+		}
+
+		if err != nil {
+			return fmt.Errorf("error finding backup: %w", err)
+		}
+
+		return restoreKubeConfig(backup, yes)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		backups, err := kubecfg.ListBackups()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		timestamps := make([]string, 0, len(backups))
+		for _, backup := range backups {
+			timestamps = append(timestamps, backup.Timestamp)
+		}
+
+		return timestamps, cobra.ShellCompDirectiveNoFileComp
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func listKubeConfigBackups() error {
+	backups, err := kubecfg.ListBackups()
+	if err != nil {
+		return fmt.Errorf("error listing backups: %w", err)
+	}
+
+	if len(backups) == 0 {
+		terminal.TextYellow("No kubeconfig backups found")
+
+		return nil
+	}
+
+	rows := make([][]string, 0, len(backups))
+	for _, backup := range backups {
+		rows = append(rows, []string{backup.Timestamp, backup.Time.Local().Format("2006-01-02 15:04:05"), backup.Path})
+	}
+
+	terminal.PrintTable([]string{"TIMESTAMP", "CREATED", "PATH"}, rows)
+
+	return nil
+}
+
+func restoreKubeConfig(backup kubecfg.Backup, yes bool) error {
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return fmt.Errorf("error reading from kubeconfig: %w", err)
+	}
+
+	restored, err := backup.Load()
+	if err != nil {
+		return fmt.Errorf("error reading from backup %s: %w", backup.Timestamp, err)
+	}
+
+	terminal.TextYellow(fmt.Sprintf("\nChanges from restoring backup %s", backup.Timestamp))
+
+	diff := kubecfg.CompareConfigs(kubeconfig, restored)
+	if diff.Empty() {
+		terminal.TextSuccess("kubeconfig already matches backup")
+
+		return nil
+	}
+
+	terminal.PrintDiff(diff)
+
+	if !yes {
+		confirmed, err := terminal.Confirm("Restore kubeconfig")
+		if err != nil {
+			return fmt.Errorf("failed to confirm restore: %w", err)
+		}
+
+		if !confirmed {
+			terminal.TextYellow("Restore cancelled")
+
+			return nil
+		}
+	}
+
+	retention := kubecfg.DefaultBackupRetention
+	if cfg != nil {
+		retention = cfg.BackupRetention()
+	}
+
+	// The current kubeconfig is backed up as part of the update, so the restore itself can be undone
+	err = kubecfg.Update(retention, func(kubeconfig *api.Config) error {
+		*kubeconfig = *restored
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update kubeconfig: %w", err)
+	}
+
+	terminal.TextSuccess(fmt.Sprintf("kubeconfig restored from %s", backup.Timestamp))
+
+	return nil
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -17,10 +18,39 @@ const (
 	exitCodeNoError  = 0
 )
 
+// annotationStdout marks the commands writing machine-readable output to stdout, set to one of the stdout* values
+// below. Every other message of those commands goes to stderr, including the ones printed while reading the config.
+const annotationStdout = "gogok8s/stdout"
+
+const (
+	// stdoutWithOutputFormat commands write to stdout when --output selects a format.
+	stdoutWithOutputFormat = "with-output-format"
+	// stdoutWithoutOutputFile commands write to stdout unless --output names a file to write to instead.
+	stdoutWithoutOutputFile = "without-output-file"
+)
+
+// annotationConfig sets how a command uses the config file, to one of the config* values below. Commands without it
+// need the config, and fail before running when it can't be read.
+const annotationConfig = "gogok8s/config"
+
+const (
+	// configSkipped commands don't read the config at all.
+	configSkipped = "skipped"
+	// configQuiet commands run without the config when it can't be read, without printing why.
+	configQuiet = "quiet"
+	// configOwn commands only need to know which config file is used, and read it themselves.
+	configOwn = "own"
+	// configOptional commands run without the config when it can't be read, with the error in cfgErr so that they can
+	// report or fix it.
+	configOptional = "optional"
+)
+
 var (
 	cfgFile string         //nolint:gochecknoglobals
 	cfg     *config.Config //nolint:gochecknoglobals
-	debug   bool           //nolint:gochecknoglobals
+	// The error loading the config file, for the commands that run without it so that it can be fixed.
+	cfgErr error //nolint:gochecknoglobals
+	debug  bool  //nolint:gochecknoglobals
 )
 
 //nolint:gochecknoglobals
@@ -28,30 +58,134 @@ var rootCmd = &cobra.Command{
 	Use:     "gogok8s",
 	Short:   "gogok8s helps manage your k8s cluster kubeconfig(s)",
 	Version: "v1.4.0",
+	// Runs before the hooks of every subcommand, see EnableTraverseRunHooks
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if debug {
+			terminal.EnableDebug()
+		}
+
+		if writesStdout(cmd) {
+			terminal.UseStderr()
+		}
+
+		return initConfig(cmd)
+	},
 }
 
 //nolint:gochecknoinits
 func init() {
-	cobra.OnInitialize(initConfig)
+	cobra.EnableTraverseRunHooks = true
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gogok8s.yaml)")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug messages")
 
 	syncCommand.Flags().Bool("dry-run", false, "performs a dryrun, showing a diff of the changes")
 	syncCommand.Flags().Bool("purge", false, "purges the kubeconfig of clusters not found")
+	syncCommand.Flags().Bool("adopt", false, "renames existing entries of the discovered clusters to the generated names")
+	syncCommand.Flags().String("on-conflict", "",
+		"how to handle entries that collide with kubeconfig entries gogok8s did not create, one of: skip|overwrite|rename")
+	syncCommand.Flags().String("on-collision", "",
+		"how to handle names generated for more than one cluster, user or context, one of: error|skip")
+	syncCommand.Flags().StringP("output", "o", "", "prints the sync results in a machine-readable format, one of: json|yaml")
+	syncCommand.Flags().Bool("watch", false, "keeps running, syncing again every --interval and logging the changes")
+	syncCommand.Flags().Duration("interval", defaultWatchInterval, "how often --watch syncs")
+	syncCommand.Flags().StringSliceP("selector", "l", nil,
+		"only syncs accounts matching the label selector, e.g. env=prod, env!=prod, team or !team")
+	syncCommand.Flags().StringSlice("exclude", nil, "account or @group to leave out, can be repeated")
+	_ = syncCommand.RegisterFlagCompletionFunc("selector", completeLabels)
+	_ = syncCommand.RegisterFlagCompletionFunc("exclude", completeAccountSelection)
 	rootCmd.AddCommand(syncCommand)
 
+	configCmd.Flags().String("name", "", "name of the account to add")
+	configCmd.Flags().String("profile", "", "AWS profile used to scan the account")
+	configCmd.Flags().StringSlice("region", nil, "AWS region to scan, can be repeated")
+	configCmd.Flags().String("format", "", "format of the generated kubeconfig entry names")
+	configCmd.Flags().StringArray("extra-user", nil, "extra user to generate contexts for as name=profile, can be repeated")
+	configCmd.Flags().Bool("discover", false, "propose accounts from the AWS config profiles and EKS users of the kubeconfig")
+	// Shares the value of --config, so the file is read before the command runs
+	configCmd.Flags().StringVar(&cfgFile, "config-file", "", "config file to add the account to, created if missing")
 	rootCmd.AddCommand(configCmd)
+
+	restoreCommand.Flags().Bool("list", false, "lists the available kubeconfig backups")
+	restoreCommand.Flags().Bool("last", false, "restores the most recent kubeconfig backup")
+	restoreCommand.Flags().BoolP("yes", "y", false, "restores the backup without asking for confirmation")
+	rootCmd.AddCommand(restoreCommand)
+
+	listCommand.Flags().Bool("cached", false, "lists the clusters found by the last scan instead of scanning again")
+	listCommand.Flags().StringP("output", "o", "", "output format, one of: json|yaml|csv")
+	rootCmd.AddCommand(listCommand)
+
+	useCommand.Flags().StringP("namespace", "n", "", "sets the default namespace of the selected context")
+	rootCmd.AddCommand(useCommand)
+
+	rootCmd.AddCommand(doctorCommand)
+
+	exportCommand.Flags().String("cluster", "", "only exports clusters whose name matches the glob pattern")
+	exportCommand.Flags().StringP("output", "o", "", "file to write the kubeconfig to, defaults to stdout")
+	exportCommand.Flags().String("current-context", "", "sets the current-context of the exported kubeconfig")
+	rootCmd.AddCommand(exportCommand)
+
+	scheduleInstallCommand.Flags().String("on-calendar", "hourly",
+		"systemd calendar expression of when to sync, e.g. hourly or 'Mon..Fri *-*-* 09:00'")
+	scheduleInstallCommand.Flags().Bool("purge", false, "passes --purge to the scheduled sync")
+	scheduleInstallCommand.Flags().String("on-conflict", "", "passes --on-conflict to the scheduled sync")
+	scheduleInstallCommand.Flags().Bool("enable", false, "runs the systemctl commands that start the timer")
+	scheduleCommand.AddCommand(scheduleInstallCommand, scheduleRemoveCommand, scheduleStatusCommand)
+	rootCmd.AddCommand(scheduleCommand)
+
+	promptCommand.Flags().String("shell", "plain",
+		"escapes colors for the shell the prompt is shown in, one of: bash|zsh|fish|plain")
+	promptCommand.Flags().Bool("no-color", false, "prints the prompt without colors")
+	promptCommand.AddCommand(promptInitCommand)
+	rootCmd.AddCommand(promptCommand)
+
+	execCommand.Flags().StringSlice("account", nil, "only runs in contexts of accounts matching the glob pattern")
+	execCommand.Flags().StringSlice("region", nil, "only runs in contexts of regions matching the glob pattern")
+	execCommand.Flags().StringSlice("cluster", nil, "only runs in contexts of EKS clusters matching the glob pattern")
+	execCommand.Flags().StringSlice("user", nil,
+		"runs in the contexts of extra users matching the glob pattern, instead of only the default contexts")
+	execCommand.Flags().IntP("parallel", "p", 4, "how many commands run at once")
+	execCommand.Flags().Bool("context-flag", false, "passes --context to the command instead of scoping KUBECONFIG")
+	rootCmd.AddCommand(execCommand)
+
+	configMigrateCommand.Flags().Bool("dry-run", false, "prints the migrated config instead of writing it")
+	configViewCommand.Flags().Bool("merged", false,
+		"prints the effective config, merged with its includes and "+config.LocalFilename)
+	configPullCommand.Flags().BoolP("yes", "y", false, "accepts the changes without asking for confirmation")
+	configValidateCommand.Flags().Bool("strict", false, "fails on warnings as well as errors")
+	configValidateCommand.Flags().StringP("output", "o", "", "prints the problems in a machine-readable format, "+
+		"one of: json|yaml")
+	configFileCommand.AddCommand(configMigrateCommand, configViewCommand, configPullCommand, configValidateCommand,
+		configSchemaCommand)
+	rootCmd.AddCommand(configFileCommand)
+
+	accountRemoveCommand.Flags().Bool("purge", false, "removes the account's kubeconfig entries without asking")
+	accountRemoveCommand.Flags().BoolP("yes", "y", false, "removes the account without asking for confirmation")
+	accountCommand.AddCommand(accountListCommand, accountShowCommand, accountEditCommand, accountRemoveCommand,
+		accountRenameCommand)
+	rootCmd.AddCommand(accountCommand)
 }
 
-func initConfig() {
+func initConfig(cmd *cobra.Command) error {
+	mode := cmd.Annotations[annotationConfig]
+	if mode == configSkipped {
+		return nil
+	}
+
+	// Completions are printed to stdout as well, so they can't be mixed with warnings
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		mode = configQuiet
+	}
+
 	if cfgFile != "" {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 	} else {
 		// Find home directory.
 		home, err := os.UserHomeDir()
-		cobra.CheckErr(err)
+		if err != nil {
+			return fmt.Errorf("failed to find user home directory: %w", err)
+		}
 
 		// Search config in home directory with name ".cobra" (without extension).
 		viper.AddConfigPath(home)
@@ -59,12 +193,81 @@ func initConfig() {
 		viper.SetConfigName(".gogok8s")
 	}
 
+	if mode == configOwn {
+		_ = viper.ReadInConfig()
+
+		return nil
+	}
+
 	if err := viper.ReadInConfig(); err != nil {
-		terminal.PrintWarning(err.Error())
-	} else {
-		cfg = config.NewConfig()
-		cobra.CheckErr(viper.Unmarshal(cfg))
+		if mode != configQuiet {
+			terminal.PrintWarning(err.Error())
+		}
+
+		return nil
+	}
+
+	file, err := config.Load(viper.ConfigFileUsed())
+	if err != nil {
+		switch mode {
+		case configQuiet:
+			return nil
+		case configOptional:
+			cfgErr = err
+
+			return nil
+		default:
+			return err
+		}
+	}
+
+	if file.NeedsMigration() {
+		terminal.PrintDebug(fmt.Sprintf("config apiVersion %q was migrated to %s in memory, run `gogok8s config "+
+			"migrate` to update the file", file.APIVersion, config.CurrentAPIVersion))
 	}
+
+	if mode != configQuiet {
+		for _, warning := range file.Warnings {
+			terminal.PrintWarning(warning)
+		}
+
+		for _, layer := range file.Layers {
+			for _, field := range layer.UnknownFields {
+				terminal.PrintWarning(fmt.Sprintf("unknown field %s in %s is ignored", field, layer.Filename))
+			}
+		}
+	}
+
+	cfg = file.Config
+
+	return nil
+}
+
+// writesStdout reports whether the command is going to write machine-readable output to stdout, see annotationStdout.
+func writesStdout(cmd *cobra.Command) bool {
+	output, _ := cmd.Flags().GetString("output")
+
+	switch cmd.Annotations[annotationStdout] {
+	case stdoutWithOutputFormat:
+		return output != ""
+	case stdoutWithoutOutputFile:
+		return output == ""
+	default:
+		return false
+	}
+}
+
+// loadOwnConfig reads the config file without merging the files it includes, or returns nil when it can't be read
+// either.
+func loadOwnConfig(filename string) *config.Config {
+	file, err := config.LoadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	terminal.PrintWarning(fmt.Sprintf("using %s on its own, without the files it includes", filename))
+
+	return file.Config
 }
 
 func Execute() int {
@@ -0,0 +1,248 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/schedule"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+//nolint:gochecknoglobals
+var scheduleCommand = &cobra.Command{
+	Use:           "schedule",
+	Short:         "manages a systemd user timer that runs sync on a schedule",
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var scheduleInstallCommand = &cobra.Command{
+	Use:   "install [accounts or @groups]",
+	Short: "writes a systemd user service and timer that sync the accounts on a calendar schedule",
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if cfg == nil {
+			return errConfigNotExist
+		}
+
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("error validating config: %w", err)
+		}
+
+		return nil
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		onCalendar, _ := cmd.Flags().GetString("on-calendar")
+		purge, _ := cmd.Flags().GetBool("purge")
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		enable, _ := cmd.Flags().GetBool("enable")
+
+		for _, account := range args {
+			// Groups are expanded when the sync runs, so changes to their accounts are picked up
+			if group, ok := strings.CutPrefix(account, config.GroupPrefix); ok {
+				if _, ok = cfg.Groups[group]; !ok {
+					return fmt.Errorf("%w: %s", config.ErrGroupNotFound, group)
+				}
+
+				continue
+			}
+
+			if _, err := cfg.GetAccount(account); err != nil {
+				return err
+			}
+		}
+
+		if onConflict != "" {
+			if _, err := kubecfg.ParseConflictPolicy(onConflict); err != nil {
+				return err
+			}
+		}
+
+		syncArgs := append([]string{"sync"}, args...)
+		if purge {
+			syncArgs = append(syncArgs, "--purge")
+		}
+
+		if onConflict != "" {
+			syncArgs = append(syncArgs, "--on-conflict", onConflict)
+		}
+
+		return installSchedule(syncArgs, onCalendar, enable)
+	},
+	ValidArgsFunction: completeAccountSelection,
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+//nolint:gochecknoglobals
+var scheduleRemoveCommand = &cobra.Command{
+	Use:   "remove",
+	Short: "stops and removes the systemd user service and timer",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return removeSchedule()
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+//nolint:gochecknoglobals
+var scheduleStatusCommand = &cobra.Command{
+	Use:   "status",
+	Short: "shows the installed schedule and when the timer runs next",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return printScheduleStatus()
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func installSchedule(syncArgs []string, onCalendar string, enable bool) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the gogok8s executable: %w", err)
+	}
+
+	// The timer doesn't run from the user's shell, so every path it depends on has to be passed explicitly
+	configFile, err := filepath.Abs(viper.ConfigFileUsed())
+	if err != nil {
+		return fmt.Errorf("failed to resolve config file: %w", err)
+	}
+
+	kubeconfigFile, err := kubecfg.DefaultPath()
+	if err != nil {
+		return fmt.Errorf("error finding kubeconfig: %w", err)
+	}
+
+	if kubeconfigFile, err = filepath.Abs(kubeconfigFile); err != nil {
+		return fmt.Errorf("failed to resolve kubeconfig: %w", err)
+	}
+
+	dir, err := schedule.UnitDir()
+	if err != nil {
+		return err
+	}
+
+	paths, err := schedule.Install(dir, schedule.Options{
+		Executable:  executable,
+		Args:        append(syncArgs, "--config", configFile),
+		OnCalendar:  onCalendar,
+		Environment: map[string]string{"KUBECONFIG": kubeconfigFile},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to install schedule: %w", err)
+	}
+
+	for _, path := range paths {
+		terminal.TextSuccess("Wrote " + path)
+	}
+
+	return runOrPrintSystemctl(schedule.EnableCommands(), enable)
+}
+
+func removeSchedule() error {
+	dir, err := schedule.UnitDir()
+	if err != nil {
+		return err
+	}
+
+	// The timer has to be stopped while its unit file still exists, otherwise it keeps running until the next login
+	_, lookErr := exec.LookPath("systemctl")
+
+	status, err := schedule.GetStatus(dir)
+	if err != nil {
+		return err
+	}
+
+	if status.Installed && lookErr == nil {
+		if err = schedule.Run(schedule.DisableCommands()); err != nil {
+			terminal.PrintWarning(err.Error())
+		}
+	}
+
+	paths, err := schedule.Remove(dir)
+	if err != nil {
+		return fmt.Errorf("failed to remove schedule: %w", err)
+	}
+
+	if len(paths) == 0 {
+		terminal.TextYellow("No schedule installed")
+
+		return nil
+	}
+
+	for _, path := range paths {
+		terminal.TextSuccess("Removed " + path)
+	}
+
+	if lookErr != nil {
+		return nil
+	}
+
+	if err = schedule.Run([][]string{{"systemctl", "--user", "daemon-reload"}}); err != nil {
+		terminal.PrintWarning(err.Error())
+	}
+
+	return nil
+}
+
+func runOrPrintSystemctl(commands [][]string, run bool) error {
+	if run {
+		return schedule.Run(commands)
+	}
+
+	terminal.TextYellow("\nRun the following to start the timer, or pass --enable next time:")
+
+	for _, command := range commands {
+		terminal.TextYellow("  " + strings.Join(command, " "))
+	}
+
+	return nil
+}
+
+func printScheduleStatus() error {
+	dir, err := schedule.UnitDir()
+	if err != nil {
+		return err
+	}
+
+	status, err := schedule.GetStatus(dir)
+	if err != nil {
+		return err
+	}
+
+	if !status.Installed {
+		terminal.TextYellow("No schedule installed, run `gogok8s schedule install` to add one")
+
+		return nil
+	}
+
+	terminal.PrintTable([]string{"SETTING", "VALUE"}, [][]string{
+		{"service", status.ServicePath},
+		{"timer", status.TimerPath},
+		{"command", status.ExecStart},
+		{"schedule", status.OnCalendar},
+	})
+
+	if _, err = exec.LookPath("systemctl"); err != nil {
+		terminal.PrintWarning("systemctl was not found, so the state of the timer is unknown")
+
+		return nil
+	}
+
+	err = schedule.Run([][]string{{"systemctl", "--user", "list-timers", "--all", "--no-pager", schedule.UnitName + ".timer"}})
+	if err != nil {
+		terminal.PrintWarning(err.Error())
+	}
+
+	return nil
+}
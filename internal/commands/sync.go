@@ -3,24 +3,29 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
 
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
 
 	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/config"
 	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
 	"github.com/BigPapaChas/gogok8s/internal/terminal"
 )
 
-var errConfigNotExist = errors.New("couldn't find .gogok8s.yaml in home directory, try running `gogok8s configure`")
+var (
+	errConfigNotExist = errors.New("couldn't find .gogok8s.yaml in home directory, try running `gogok8s configure`")
+	errNameCollisions = errors.New("generated names collide, pass --on-collision skip or set onCollision to leave " +
+		"the colliding clusters out")
+)
 
 //nolint:gochecknoglobals
 var syncCommand = &cobra.Command{
-	Use:   "sync [accounts]",
+	Use:   "sync [accounts or @groups]",
 	Short: "syncs your kubeconfig with all available k8s clusters",
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		if debug {
-			terminal.EnableDebug()
-		}
 		if cfg == nil {
 			return errConfigNotExist
 		}
@@ -29,72 +34,280 @@ var syncCommand = &cobra.Command{
 			return fmt.Errorf("error validating config: %w", err)
 		}
 
+		for _, problem := range cfg.FormatCollisions() {
+			terminal.PrintWarning(problem.Error())
+		}
+
 		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		dryRun, _ := cmd.Flags().GetBool("dry-run")
 		purge, _ := cmd.Flags().GetBool("purge")
+		adopt, _ := cmd.Flags().GetBool("adopt")
+		output, _ := cmd.Flags().GetString("output")
+		onConflict, _ := cmd.Flags().GetString("on-conflict")
+		onCollision, _ := cmd.Flags().GetString("on-collision")
+		watch, _ := cmd.Flags().GetBool("watch")
+		interval, _ := cmd.Flags().GetDuration("interval")
 
-		return syncKubernetesClusters(args, dryRun, purge)
-	},
-	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-		if cfg != nil {
-			return cfg.ListAccountNamesFiltered(args), cobra.ShellCompDirectiveNoFileComp
+		format, err := terminal.ParseOutputFormat(output, terminal.OutputJSON, terminal.OutputYAML)
+		if err != nil {
+			return err
 		}
 
-		return nil, cobra.ShellCompDirectiveNoFileComp
+		// The --on-conflict flag takes precedence over the policy within the config file
+		policy := cfg.ConflictPolicy()
+		if onConflict != "" {
+			if policy, err = kubecfg.ParseConflictPolicy(onConflict); err != nil {
+				return err
+			}
+		}
+
+		collisionPolicy := cfg.CollisionPolicy()
+		if onCollision != "" {
+			if collisionPolicy, err = kubecfg.ParseCollisionPolicy(onCollision); err != nil {
+				return err
+			}
+		}
+
+		opts := syncOptions{
+			DryRun:      dryRun,
+			Purge:       purge,
+			Adopt:       adopt,
+			Output:      format,
+			OnConflict:  policy,
+			OnCollision: collisionPolicy,
+		}
+
+		selector := config.AccountSelector{Accounts: args}
+		selector.Labels, _ = cmd.Flags().GetStringSlice("selector")
+		selector.Exclude, _ = cmd.Flags().GetStringSlice("exclude")
+
+		accounts, err := cfg.SelectAccounts(selector)
+		if err != nil {
+			return err
+		}
+
+		if watch {
+			return watchKubernetesClusters(accounts, opts, interval)
+		}
+
+		return syncKubernetesClusters(accounts, opts)
 	},
-	SilenceErrors: true,
-	SilenceUsage:  true,
+	ValidArgsFunction: completeAccountSelection,
+	Annotations:       map[string]string{annotationStdout: stdoutWithOutputFormat},
+	SilenceErrors:     true,
+	SilenceUsage:      true,
+}
+
+type syncOptions struct {
+	DryRun      bool
+	Purge       bool
+	Adopt       bool
+	Output      terminal.OutputFormat
+	OnConflict  kubecfg.ConflictPolicy
+	OnCollision kubecfg.CollisionPolicy
 }
 
-func syncKubernetesClusters(accounts []string, dryRun, purge bool) error {
-	kubeconfig, err := kubecfg.LoadDefault()
+// syncReport is the machine-readable result of a sync, written when --output is used.
+type syncReport struct {
+	DryRun   bool                `json:"dryRun" yaml:"dryRun"`
+	Accounts []syncAccountReport `json:"accounts" yaml:"accounts"`
+	// Entries of other tools renamed to the names gogok8s generates, before the accounts were applied.
+	Adopted *kubecfg.Diff `json:"adopted,omitempty" yaml:"adopted,omitempty"`
+	Purged  *kubecfg.Diff `json:"purged,omitempty" yaml:"purged,omitempty"`
+	// Names generated for more than one entry, whose clusters were left out.
+	Collisions []kubecfg.Collision `json:"collisions,omitempty" yaml:"collisions,omitempty"`
+}
+
+type syncAccountReport struct {
+	Name     string        `json:"name" yaml:"name"`
+	Regions  []string      `json:"regions" yaml:"regions"`
+	Clusters []string      `json:"clusters" yaml:"clusters"`
+	Errors   []string      `json:"errors" yaml:"errors"`
+	Changes  *kubecfg.Diff `json:"changes" yaml:"changes"`
+}
+
+func syncKubernetesClusters(eksAccounts []clusters.ClusterAccount, opts syncOptions) error {
+	if len(eksAccounts) == 0 {
+		// Scripts parsing the output still get a report, just without accounts
+		if opts.Output != terminal.OutputText {
+			return terminal.WriteStructured(opts.Output, &syncReport{DryRun: opts.DryRun, Accounts: []syncAccountReport{}})
+		}
+
+		terminal.TextYellow("No accounts matched, nothing to sync")
+
+		return nil
+	}
+
+	patch, results := fetchKubeConfigFromAccounts(eksAccounts)
+
+	patch, collisions, err := resolveCollisions(patch, results, opts.OnCollision)
 	if err != nil {
-		return fmt.Errorf("error reading from kubeconfig: %w", err)
+		terminal.PrintCollisions(collisions)
+
+		return err
 	}
 
-	// If no accounts were passed to the command, fetch from all accounts
-	var eksAccounts []clusters.ClusterAccount
-	if len(accounts) == 0 {
-		eksAccounts = cfg.GetAccounts()
+	var report *syncReport
+
+	if opts.DryRun {
+		kubeconfig, err := kubecfg.LoadDefault()
+		if err != nil {
+			return fmt.Errorf("error reading from kubeconfig: %w", err)
+		}
+
+		report = applyKubeConfigResults(kubeconfig, patch, results, opts)
 	} else {
-		eksAccounts = cfg.ListAccountsFiltered(accounts)
-	}
+		// The kubeconfig is loaded, patched and written while holding its lock so concurrent runs can't clobber each other
+		err = kubecfg.Update(cfg.BackupRetention(), func(kubeconfig *api.Config) error {
+			report = applyKubeConfigResults(kubeconfig, patch, results, opts)
 
-	if len(eksAccounts) == 0 {
-		return nil
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update kubeconfig: %w", err)
+		}
 	}
 
-	patch := fetchKubeConfigFromAccounts(eksAccounts)
+	report.Collisions = collisions
 
-	kubecfg.ApplyPatch(patch, kubeconfig, purge)
+	if opts.Output != terminal.OutputText {
+		return terminal.WriteStructured(opts.Output, report)
+	}
 
-	if dryRun {
+	terminal.PrintCollisions(collisions)
+
+	if opts.DryRun {
+		terminal.TextYellow("\nChanges to kubeconfig")
+		terminal.PrintDiff(report.diff())
 		terminal.TextSuccess("Dryrun complete")
+	} else {
+		terminal.TextYellow("\nApplied changes to kubeconfig")
+		terminal.PrintDiff(report.diff())
+		terminal.TextSuccess("kubeconfig updated")
+	}
 
-		return nil
+	return nil
+}
+
+// resolveCollisions checks the merged patch for names generated for more than one entry, which would otherwise be
+// decided by the order the accounts are applied in. Unless the policy skips them, an error is returned and nothing may
+// be applied. Skipped entries are removed from the patch of each result as well.
+func resolveCollisions(
+	patch *kubecfg.KubeConfigPatch,
+	results []KubeConfigResult,
+	policy kubecfg.CollisionPolicy,
+) (*kubecfg.KubeConfigPatch, []kubecfg.Collision, error) {
+	collisions := kubecfg.FindCollisions(patch)
+	if len(collisions) == 0 {
+		return patch, nil, nil
 	}
 
-	err = kubecfg.Write(kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed to write to kubeconfig: %w", err)
+	if policy != kubecfg.CollisionSkip {
+		return patch, collisions, fmt.Errorf("%w: %d names collide", errNameCollisions, len(collisions))
 	}
 
-	terminal.TextSuccess("kubeconfig updated")
+	for idx := range results {
+		results[idx].Patch = kubecfg.RemoveCollisions(results[idx].Patch, collisions)
+	}
 
-	return nil
+	return kubecfg.RemoveCollisions(patch, collisions), collisions, nil
+}
+
+// applyKubeConfigResults applies the patch of each account in turn so that every change can be attributed to the
+// account it came from. Adopting and purging have to consider the patches of all accounts, so existing entries are
+// adopted first and purging is applied last.
+func applyKubeConfigResults(
+	kubeconfig *api.Config,
+	patch *kubecfg.KubeConfigPatch,
+	results []KubeConfigResult,
+	opts syncOptions,
+) *syncReport {
+	report := &syncReport{DryRun: opts.DryRun}
+
+	if opts.Adopt {
+		report.Adopted = kubecfg.Adopt(patch, kubeconfig)
+	}
+
+	for _, result := range results {
+		accountReport := syncAccountReport{
+			Name:     result.AccountName,
+			Regions:  result.Regions,
+			Clusters: []string{},
+			Errors:   []string{},
+			Changes:  kubecfg.ApplyPatch(result.Patch, kubeconfig, kubecfg.ApplyOptions{OnConflict: opts.OnConflict}),
+		}
+
+		for _, cluster := range result.Patch.Clusters {
+			accountReport.Clusters = append(accountReport.Clusters, cluster.Name)
+		}
+
+		sort.Strings(accountReport.Clusters)
+
+		for _, err := range result.Errors {
+			accountReport.Errors = append(accountReport.Errors, err.Error())
+		}
+
+		report.Accounts = append(report.Accounts, accountReport)
+	}
+
+	if opts.Purge {
+		report.Purged = kubecfg.Purge(patch, kubeconfig)
+	}
+
+	return report
+}
+
+// diff merges the changes of every account into a single diff.
+func (r *syncReport) diff() *kubecfg.Diff {
+	diff := &kubecfg.Diff{}
+	if r.Adopted != nil {
+		diff.Merge(r.Adopted)
+	}
+
+	for _, account := range r.Accounts {
+		diff.Merge(account.Changes)
+	}
+
+	if r.Purged != nil {
+		diff.Merge(r.Purged)
+	}
+
+	return diff
 }
 
 type KubeConfigResult struct {
 	Patch       *kubecfg.KubeConfigPatch
 	Errors      []error
 	AccountName string
+	Regions     []string
 }
 
-func fetchKubeConfigFromAccounts(accounts []clusters.ClusterAccount) *kubecfg.KubeConfigPatch {
-	kubeconfig := &kubecfg.KubeConfigPatch{}
+func fetchKubeConfigFromAccounts(accounts []clusters.ClusterAccount) (*kubecfg.KubeConfigPatch, []KubeConfigResult) {
 	spinner, _ := terminal.StartNewSpinner("Scanning accounts for Kubernetes clusters...")
+
+	kubeconfig, results := generateKubeConfigFromAccounts(accounts, func(result KubeConfigResult) {
+		if len(result.Errors) > 0 {
+			terminal.TextWarning(result.AccountName)
+			terminal.PrintBulletedWarnings(result.Errors)
+		} else {
+			terminal.TextSuccess(result.AccountName)
+		}
+	})
+
+	_ = spinner.Stop()
+
+	return kubeconfig, results
+}
+
+// generateKubeConfigFromAccounts generates the kubeconfig of every account concurrently, calling onResult as each
+// account finishes.
+func generateKubeConfigFromAccounts(
+	accounts []clusters.ClusterAccount,
+	onResult func(result KubeConfigResult),
+) (*kubecfg.KubeConfigPatch, []KubeConfigResult) {
+	kubeconfig := &kubecfg.KubeConfigPatch{}
 	ch := make(chan KubeConfigResult, len(accounts))
 
 	for _, account := range accounts {
@@ -105,26 +318,55 @@ func fetchKubeConfigFromAccounts(accounts []clusters.ClusterAccount) *kubecfg.Ku
 				Patch:       kubeconfigPatch,
 				Errors:      errors,
 				AccountName: account.PrettyName(),
+				Regions:     account.ListRegions(),
 			}
 		}(account)
 	}
 
+	results := make([]KubeConfigResult, 0, len(accounts))
+
 	for range accounts {
 		result := <-ch
+		results = append(results, result)
 
 		kubeconfig.Clusters = append(kubeconfig.Clusters, result.Patch.Clusters...)
 		kubeconfig.Users = append(kubeconfig.Users, result.Patch.Users...)
 		kubeconfig.Contexts = append(kubeconfig.Contexts, result.Patch.Contexts...)
 
-		if len(result.Errors) > 0 {
-			terminal.TextWarning(result.AccountName)
-			terminal.PrintBulletedWarnings(result.Errors)
-		} else {
-			terminal.TextSuccess(result.AccountName)
+		onResult(result)
+	}
+
+	// Results arrive in whatever order the accounts finish scanning, sort them so output is stable between runs
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].AccountName < results[j].AccountName
+	})
+
+	return kubeconfig, results
+}
+
+// completeAccountSelection completes the account names and @groups that haven't been passed yet.
+func completeAccountSelection(
+	cmd *cobra.Command, args []string, toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	if cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var completions []string
+
+	for _, name := range append(cfg.ListAccountNamesFiltered(nil), cfg.ListGroupNames()...) {
+		if !slices.Contains(args, name) {
+			completions = append(completions, name)
 		}
 	}
 
-	_ = spinner.Stop()
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeLabels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if cfg == nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
 
-	return kubeconfig
+	return cfg.ListLabels(), cobra.ShellCompDirectiveNoFileComp
 }
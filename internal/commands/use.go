@@ -0,0 +1,124 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+var errNoManagedContexts = errors.New("no contexts managed by gogok8s were found, try running `gogok8s sync`")
+
+//nolint:gochecknoglobals
+var useCommand = &cobra.Command{
+	Use:   "use [query]",
+	Short: "switches the current-context to one of the contexts managed by gogok8s",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		namespace, _ := cmd.Flags().GetString("namespace")
+
+		var query string
+		if len(args) == 1 {
+			query = args[0]
+		}
+
+		return useContext(query, namespace)
+	},
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		kubeconfig, err := kubecfg.LoadDefault()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		var names []string
+		for _, context := range kubecfg.ListManagedContexts(kubeconfig) {
+			names = append(names, context.Name)
+		}
+
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	SilenceErrors: true,
+	SilenceUsage:  true,
+}
+
+func useContext(query, namespace string) error {
+	kubeconfig, err := kubecfg.LoadDefault()
+	if err != nil {
+		return fmt.Errorf("error reading from kubeconfig: %w", err)
+	}
+
+	contexts := kubecfg.ListManagedContexts(kubeconfig)
+	if len(contexts) == 0 {
+		return errNoManagedContexts
+	}
+
+	name, err := selectContext(contexts, kubeconfig.CurrentContext, query)
+	if err != nil {
+		return err
+	}
+
+	err = kubecfg.Modify(func(kubeconfig *api.Config) error {
+		return kubecfg.SetCurrentContext(kubeconfig, name, namespace)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to switch context: %w", err)
+	}
+
+	if namespace != "" {
+		terminal.TextSuccess(fmt.Sprintf("Switched to context %s with namespace %s", name, namespace))
+	} else {
+		terminal.TextSuccess("Switched to context " + name)
+	}
+
+	return nil
+}
+
+// selectContext returns the context matching the query, only prompting the user when the query doesn't match exactly
+// one context.
+func selectContext(contexts []kubecfg.ManagedContext, currentContext, query string) (string, error) {
+	items := make([]terminal.SelectItem, 0, len(contexts))
+
+	for _, context := range contexts {
+		if context.Name == query {
+			return context.Name, nil
+		}
+
+		label := context.Name
+		if context.Name == currentContext {
+			label += " *"
+		}
+
+		description := context.Metadata.ClusterArn
+		if context.Metadata.ExtraUser != "" {
+			description += " (user: " + context.Metadata.ExtraUser + ")"
+		}
+
+		items = append(items, terminal.SelectItem{
+			Group:       context.Metadata.Account + " / " + context.Metadata.Region,
+			Label:       label,
+			Description: description,
+			Search:      context.Name + " " + context.Metadata.ClusterArn + " " + context.Metadata.ExtraUser,
+		})
+	}
+
+	if query != "" {
+		if matches := terminal.FilterItems(items, query); len(matches) == 1 {
+			return contexts[matches[0]].Name, nil
+		}
+	}
+
+	idx, err := terminal.FuzzySelect("Context", items, query)
+	if err != nil {
+		return "", fmt.Errorf("failed to select context: %w", err)
+	}
+
+	return contexts[idx].Name, nil
+}
@@ -0,0 +1,256 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+const (
+	defaultWatchInterval = 15 * time.Minute
+	minWatchInterval     = time.Minute
+	maxWatchBackoff      = time.Hour
+)
+
+var (
+	errWatchIntervalTooShort = fmt.Errorf("--interval must be at least %s", minWatchInterval)
+	errWatchOutputFormat     = errors.New("--watch only supports --output json")
+)
+
+// watcher re-syncs the kubeconfig on an interval, logging every change instead of printing it for a person to read.
+// Accounts whose credentials have expired are retried less and less often, so an expired session doesn't turn into a
+// steady stream of failing STS calls.
+type watcher struct {
+	accounts []clusters.ClusterAccount
+	opts     syncOptions
+	interval time.Duration
+	logger   *slog.Logger
+	backoffs map[string]*accountBackoff
+}
+
+type accountBackoff struct {
+	failures int
+	retryAt  time.Time
+}
+
+func watchKubernetesClusters(accounts []clusters.ClusterAccount, opts syncOptions, interval time.Duration) error {
+	if interval < minWatchInterval {
+		return errWatchIntervalTooShort
+	}
+
+	var handler slog.Handler
+
+	switch opts.Output {
+	case terminal.OutputText:
+		handler = slog.NewTextHandler(os.Stderr, nil)
+	case terminal.OutputJSON:
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	default:
+		return errWatchOutputFormat
+	}
+
+	w := &watcher{
+		accounts: accounts,
+		opts:     opts,
+		interval: interval,
+		logger:   slog.New(handler),
+		backoffs: make(map[string]*accountBackoff),
+	}
+
+	if len(w.accounts) == 0 {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w.logger.Info("watching accounts", "accounts", len(w.accounts), "interval", interval.String())
+
+	for {
+		w.sync()
+
+		select {
+		case <-ctx.Done():
+			w.logger.Info("stopping watch")
+
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (w *watcher) sync() {
+	now := time.Now()
+
+	var due []clusters.ClusterAccount
+
+	for _, account := range w.accounts {
+		if backoff, ok := w.backoffs[account.PrettyName()]; ok && now.Before(backoff.retryAt) {
+			w.logger.Debug("skipping account", "account", account.PrettyName(), "retryAt", backoff.retryAt)
+
+			continue
+		}
+
+		due = append(due, account)
+	}
+
+	if len(due) == 0 {
+		return
+	}
+
+	patch, results := generateKubeConfigFromAccounts(due, w.logResult)
+
+	patch, collisions, err := resolveCollisions(patch, results, w.opts.OnCollision)
+	for _, collision := range collisions {
+		accounts := make([]string, 0, len(collision.Sources))
+		for _, source := range collision.Sources {
+			accounts = append(accounts, source.Account+"/"+source.Region+"/"+source.ClusterName)
+		}
+
+		w.logger.Warn("name collision", "kind", collision.Kind, "name", collision.Name, "clusters", accounts)
+	}
+
+	if err != nil {
+		w.logger.Error("not updating kubeconfig", "error", err)
+
+		return
+	}
+
+	// Purging with a partial patch would remove the entries of every account that was skipped or failed to scan
+	opts := w.opts
+	if opts.Purge && !w.scannedCleanly(due, results) {
+		w.logger.Warn("skipping purge, not every account was scanned successfully")
+
+		opts.Purge = false
+	}
+
+	var diff *kubecfg.Diff
+
+	if opts.DryRun {
+		kubeconfig, err := kubecfg.LoadDefault()
+		if err != nil {
+			w.logger.Error("failed to read kubeconfig", "error", err)
+
+			return
+		}
+
+		diff = applyKubeConfigResults(kubeconfig, patch, results, opts).diff()
+	} else {
+		// Applied once while holding the lock, the kubeconfig is only backed up and written when something changed
+		err = kubecfg.Update(cfg.BackupRetention(), func(kubeconfig *api.Config) error {
+			diff = applyKubeConfigResults(kubeconfig, patch, results, opts).diff()
+
+			return nil
+		})
+		if err != nil {
+			w.logger.Error("failed to update kubeconfig", "error", err)
+
+			return
+		}
+	}
+
+	var attrs []any
+	if opts.DryRun {
+		attrs = append(attrs, "dryRun", true)
+	}
+
+	// Conflicts are logged even when nothing changed, since they keep clusters out of the kubeconfig
+	w.logDiff(diff, attrs...)
+
+	switch {
+	case diff.Empty():
+		w.logger.Info("kubeconfig up to date")
+	case !opts.DryRun:
+		w.logger.Info("kubeconfig updated")
+	}
+}
+
+// logResult logs the outcome of scanning an account, backing off the account when its credentials have expired.
+func (w *watcher) logResult(result KubeConfigResult) {
+	credentialsExpired := false
+
+	for _, err := range result.Errors {
+		w.logger.Warn("error scanning account", "account", result.AccountName, "error", err)
+
+		if clusters.IsCredentialsError(err) {
+			credentialsExpired = true
+		}
+	}
+
+	if !credentialsExpired {
+		delete(w.backoffs, result.AccountName)
+
+		if len(result.Errors) == 0 {
+			w.logger.Info("scanned account", "account", result.AccountName, "clusters", len(result.Patch.Clusters))
+		}
+
+		return
+	}
+
+	backoff, ok := w.backoffs[result.AccountName]
+	if !ok {
+		backoff = &accountBackoff{}
+		w.backoffs[result.AccountName] = backoff
+	}
+
+	backoff.failures++
+	backoff.retryAt = time.Now().Add(w.backoffDelay(backoff.failures))
+
+	w.logger.Warn("credentials expired, backing off", "account", result.AccountName, "failures", backoff.failures,
+		"retryAt", backoff.retryAt)
+}
+
+// backoffDelay doubles the interval with every consecutive failure, up to maxWatchBackoff.
+func (w *watcher) backoffDelay(failures int) time.Duration {
+	delay := w.interval
+	for i := 0; i < failures && delay < maxWatchBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, max(maxWatchBackoff, w.interval))
+}
+
+func (w *watcher) scannedCleanly(due []clusters.ClusterAccount, results []KubeConfigResult) bool {
+	if len(due) != len(w.accounts) {
+		return false
+	}
+
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (w *watcher) logDiff(diff *kubecfg.Diff, attrs ...any) {
+	if diff.CurrentContext != nil {
+		w.logger.Info("current-context changed", append(attrs, "old", diff.CurrentContext.Old,
+			"new", diff.CurrentContext.New)...)
+	}
+
+	kinds := []string{kubecfg.KindCluster, kubecfg.KindUser, kubecfg.KindContext}
+	for idx, changes := range [][]kubecfg.Change{diff.Clusters, diff.Users, diff.Contexts} {
+		for _, change := range changes {
+			w.logger.Info("kubeconfig change", append(attrs, "kind", kinds[idx], "name", change.Name,
+				"type", change.Type)...)
+		}
+	}
+
+	for _, conflict := range diff.Conflicts {
+		w.logger.Warn("kubeconfig conflict", append(attrs, "kind", conflict.Kind, "name", conflict.Name,
+			"resolution", conflict.Resolution)...)
+	}
+}
@@ -3,19 +3,86 @@ package config
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
 
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v3"
 
 	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
 	"github.com/BigPapaChas/gogok8s/internal/terminal"
 )
 
 type Config struct {
+	// The layout version of the config file, see CurrentAPIVersion.
+	APIVersion string `yaml:"apiVersion"`
+	// A config fragment maintained elsewhere, e.g. by a platform team, merged beneath this file and everything it
+	// includes. It is fetched by `gogok8s config pull` and pinned by its SHA-256.
+	Source *SourceConfig `yaml:"source,omitempty"`
+	// Paths or globs of config files merged beneath this one, relative to its directory.
+	Include []string `yaml:"include,omitempty"`
+	// Settings inherited by every account that doesn't set them itself.
+	Defaults AccountDefaults       `yaml:"defaults,omitempty"`
 	Accounts []clusters.EKSAccount `yaml:"accounts"`
+	// Named lists of accounts, selected with @name.
+	Groups  map[string][]string `yaml:"groups,omitempty"`
+	Backups BackupConfig        `yaml:"backups,omitempty"`
+	// How sync handles generated entries whose names collide with kubeconfig entries gogok8s did not create, one of
+	// skip, overwrite or rename. Defaults to skip.
+	OnConflict string `yaml:"onConflict,omitempty"`
+	// How sync handles names generated for more than one cluster, user or context, one of error or skip. Defaults to
+	// error.
+	OnCollision string       `yaml:"onCollision,omitempty"`
+	Prompt      PromptConfig `yaml:"prompt,omitempty"`
+
+	// Set by Load when the config was merged from several files. own is the config file itself, which is what gets
+	// written, and sources maps the path of every setting to the file it came from. unsetEnv maps the path of every
+	// setting that uses an environment variable that isn't set to the variable's error.
+	own      *Config
+	filename string
+	sources  map[string]string
+	unsetEnv map[string]error
 }
 
+type SourceConfig struct {
+	// An https URL, or a file within a local git checkout as <repo>//<path>, optionally followed by ?ref=<ref>.
+	URL string `yaml:"url"`
+	// The SHA-256 of the accepted contents. Cached contents that don't match it are ignored.
+	SHA256 string `yaml:"sha256,omitempty"`
+}
+
+type AccountDefaults struct {
+	Regions    []string           `yaml:"regions,omitempty"`
+	Format     string             `yaml:"format,omitempty"`
+	ExtraUsers []clusters.EKSUser `yaml:"extraUsers,omitempty"`
+}
+
+type BackupConfig struct {
+	// The number of kubeconfig backups to keep, defaults to kubecfg.DefaultBackupRetention when unset.
+	Retention int `yaml:"retention,omitempty"`
+}
+
+type PromptConfig struct {
+	// The format of `gogok8s prompt`, using ${account}, ${region}, ${cluster}, ${user}, ${context} and ${namespace}.
+	Format string `yaml:"format,omitempty"`
+	// The colors of the prompt for accounts that are and aren't marked as production.
+	Color           string `yaml:"color,omitempty"`
+	ProductionColor string `yaml:"productionColor,omitempty"`
+}
+
+// PromptVariables are the variables that can be used within the format of the prompt.
+//
+//nolint:gochecknoglobals
+var PromptVariables = []string{"${account}", "${region}", "${cluster}", "${user}", "${context}", "${namespace}"}
+
+const (
+	DefaultPromptFormat          = "${account}/${region}/${cluster}${user}"
+	DefaultPromptColor           = "cyan"
+	DefaultPromptProductionColor = "red"
+)
+
 //nolint:gochecknoglobals
 var ValidRegions = []string{
 	"us-east-1",
@@ -41,58 +108,151 @@ const configFilemode = os.FileMode(0o644)
 
 var (
 	ErrDuplicateAccountName = errors.New("account with that name already exists")
+	ErrAccountNotFound      = errors.New("account not found")
 	ErrInvalidAWSRegion     = errors.New("invalid AWS region")
 	ErrMustContainAWSRegion = errors.New("account must contain at least one region")
+	ErrCollidingFormat      = errors.New("format can generate the same name for different clusters")
 )
 
 func NewConfig() *Config {
-	return &Config{}
+	return &Config{APIVersion: CurrentAPIVersion}
 }
 
 func (c *Config) GetAccounts() []clusters.ClusterAccount {
 	var accounts []clusters.ClusterAccount
 	for _, account := range c.Accounts {
-		accounts = append(accounts, account)
+		accounts = append(accounts, c.WithDefaults(account))
 	}
 
 	return accounts
 }
 
-func (c *Config) Validate() error {
-	accountNames := make(map[string]struct{})
+// ListAccounts returns every account with the defaults applied.
+func (c *Config) ListAccounts() []clusters.EKSAccount {
+	accounts := make([]clusters.EKSAccount, 0, len(c.Accounts))
+	for _, account := range c.Accounts {
+		accounts = append(accounts, c.WithDefaults(account))
+	}
 
-	for idx, account := range c.Accounts {
-		// validate that there are no duplicate account names
-		if _, ok := accountNames[account.Name]; !ok {
-			accountNames[account.Name] = struct{}{}
-		} else {
-			return duplicateAccountError(fmt.Sprintf("`%s` at accounts[%d]", account.Name, idx))
-		}
+	return accounts
+}
 
-		// validate that each account has at least one valid region
-		if len(account.Regions) == 0 {
-			return accountHasNoRegionsError(account.Name)
-		}
+// WithDefaults returns the account with every setting it doesn't set itself inherited from the defaults, and its
+// additional regions added to its regions.
+func (c *Config) WithDefaults(account clusters.EKSAccount) clusters.EKSAccount {
+	if len(account.Regions) == 0 {
+		account.Regions = slices.Clone(c.Defaults.Regions)
+	}
 
-		// validate each region is a valid AWS region
-		for _, region := range account.Regions {
-			if !isValidRegion(region) {
-				return invalidRegionError(fmt.Sprintf("region %s in account %s", region, account.Name))
-			}
+	for _, region := range account.AdditionalRegions {
+		if !slices.Contains(account.Regions, region) {
+			account.Regions = append(account.Regions, region)
 		}
 	}
 
-	return nil
+	account.AdditionalRegions = nil
+
+	if account.Format == "" {
+		account.Format = c.Defaults.Format
+	}
+
+	if len(account.ExtraUsers) == 0 {
+		account.ExtraUsers = slices.Clone(c.Defaults.ExtraUsers)
+	}
+
+	return account
+}
+
+// WithoutDefaults is the reverse of WithDefaults, unsetting the settings of the account that match the defaults so it
+// keeps following any change to them. Regions that extend the default regions become additional regions.
+func (c *Config) WithoutDefaults(account clusters.EKSAccount) clusters.EKSAccount {
+	defaultRegions := c.Defaults.Regions
+	if len(defaultRegions) > 0 && len(account.Regions) >= len(defaultRegions) &&
+		slices.Equal(account.Regions[:len(defaultRegions)], defaultRegions) {
+		account.AdditionalRegions = slices.Clone(account.Regions[len(defaultRegions):])
+		account.Regions = nil
+	}
+
+	if account.Format == c.DefaultFormat() {
+		account.Format = ""
+	}
+
+	if slices.Equal(account.ExtraUsers, c.Defaults.ExtraUsers) {
+		account.ExtraUsers = nil
+	}
+
+	return account
+}
+
+// DefaultFormat returns the format of accounts that don't set one.
+func (c *Config) DefaultFormat() string {
+	if c.Defaults.Format == "" {
+		return clusters.DefaultFormat
+	}
+
+	return c.Defaults.Format
+}
+
+func (c *Config) BackupRetention() int {
+	if c.Backups.Retention <= 0 {
+		return kubecfg.DefaultBackupRetention
+	}
+
+	return c.Backups.Retention
+}
+
+func (c *Config) ConflictPolicy() kubecfg.ConflictPolicy {
+	policy, err := kubecfg.ParseConflictPolicy(c.OnConflict)
+	if err != nil {
+		return kubecfg.ConflictSkip
+	}
+
+	return policy
+}
+
+func (c *Config) CollisionPolicy() kubecfg.CollisionPolicy {
+	policy, err := kubecfg.ParseCollisionPolicy(c.OnCollision)
+	if err != nil {
+		return kubecfg.CollisionError
+	}
+
+	return policy
+}
+
+// PromptSettings returns the prompt config with defaults filled in.
+func (c *Config) PromptSettings() PromptConfig {
+	prompt := c.Prompt
+
+	if prompt.Format == "" {
+		prompt.Format = DefaultPromptFormat
+	}
+
+	if prompt.Color == "" {
+		prompt.Color = DefaultPromptColor
+	}
+
+	if prompt.ProductionColor == "" {
+		prompt.ProductionColor = DefaultPromptProductionColor
+	}
+
+	return prompt
 }
 
 func (c *Config) Write() error {
 	return c.WriteToFile(viper.ConfigFileUsed())
 }
 
+// WriteToFile writes the config. When it was merged from several files, only the settings of the config file itself are
+// written, leaving out everything that came from the files it includes.
 func (c *Config) WriteToFile(filename string) error {
-	data, err := yaml.Marshal(c)
+	target := c
+	if c.own != nil {
+		target = c.own
+	}
+
+	data, err := target.Marshal()
 	if err != nil {
-		return fmt.Errorf("failed to marshal config yaml: %w", err)
+		return err
 	}
 
 	if err = os.WriteFile(filename, data, configFilemode); err != nil {
@@ -102,8 +262,156 @@ func (c *Config) WriteToFile(filename string) error {
 	return nil
 }
 
+func (c *Config) Marshal() ([]byte, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config yaml: %w", err)
+	}
+
+	return data, nil
+}
+
+// SetSource replaces the source of the config, which is always written to the config file itself.
+func (c *Config) SetSource(src *SourceConfig) {
+	c.Source = src
+
+	if c.own != nil {
+		c.own.Source = src
+	}
+}
+
+// AddAccount adds the account, leaving the settings that match the defaults unset.
 func (c *Config) AddAccount(account clusters.EKSAccount) {
+	account = c.WithoutDefaults(account)
 	c.Accounts = append(c.Accounts, account)
+
+	if c.own != nil {
+		c.own.AddAccount(account)
+	}
+}
+
+// GetAccount returns the account with the defaults applied.
+func (c *Config) GetAccount(name string) (clusters.EKSAccount, error) {
+	idx, err := c.findAccount(name)
+	if err != nil {
+		return clusters.EKSAccount{}, err
+	}
+
+	return c.WithDefaults(c.Accounts[idx]), nil
+}
+
+// UpdateAccount replaces the account with the same name, leaving the settings that match the defaults unset. Only the
+// settings that differ from the merged account are written to the config file itself, so that settings of included
+// files and .gogok8s.local.yaml aren't copied into it, and an account defined in an included file is overridden by
+// them. Settings that still match what their ${env:...} templates expand to are written as the templates.
+func (c *Config) UpdateAccount(account clusters.EKSAccount) error {
+	idx, err := c.findAccount(account.Name)
+	if err != nil {
+		return err
+	}
+
+	before := c.WithDefaults(c.Accounts[idx])
+	account = c.WithoutDefaults(account)
+
+	c.Accounts[idx] = account
+
+	if c.own != nil {
+		own := clusters.EKSAccount{Name: account.Name}
+		if ownIdx, err := c.own.findAccount(account.Name); err == nil {
+			own = c.own.Accounts[ownIdx]
+		}
+
+		updated := keepTemplates(changedSettings(own, account, before, c.WithDefaults(account)), own)
+
+		if c.own.UpdateAccount(updated) != nil {
+			c.own.AddAccount(updated)
+		}
+	}
+
+	return nil
+}
+
+// changedSettings sets the settings of own to the ones of account wherever the account with defaults applied differs
+// between before and after.
+func changedSettings(own, account, before, after clusters.EKSAccount) clusters.EKSAccount {
+	if after.Profile != before.Profile {
+		own.Profile = account.Profile
+	}
+
+	if !slices.Equal(after.Regions, before.Regions) {
+		own.Regions = account.Regions
+		own.AdditionalRegions = account.AdditionalRegions
+	}
+
+	if after.Format != before.Format {
+		own.Format = account.Format
+	}
+
+	if !slices.Equal(after.ExtraUsers, before.ExtraUsers) {
+		own.ExtraUsers = account.ExtraUsers
+	}
+
+	if after.Production != before.Production {
+		own.Production = account.Production
+	}
+
+	if !maps.Equal(after.Labels, before.Labels) {
+		own.Labels = account.Labels
+	}
+
+	return own
+}
+
+func (c *Config) RemoveAccount(name string) error {
+	idx, err := c.findAccount(name)
+	if err != nil {
+		return err
+	}
+
+	if err = c.checkAccountDefined(name); err != nil {
+		return err
+	}
+
+	c.Accounts = append(c.Accounts[:idx], c.Accounts[idx+1:]...)
+
+	if c.own != nil {
+		return c.own.RemoveAccount(name)
+	}
+
+	return nil
+}
+
+func (c *Config) RenameAccount(oldName, newName string) error {
+	idx, err := c.findAccount(oldName)
+	if err != nil {
+		return err
+	}
+
+	if err = c.IsValidAccountName(newName); err != nil {
+		return err
+	}
+
+	if err = c.checkAccountDefined(oldName); err != nil {
+		return err
+	}
+
+	c.Accounts[idx].Name = newName
+
+	if c.own != nil {
+		return c.own.RenameAccount(oldName, newName)
+	}
+
+	return nil
+}
+
+func (c *Config) findAccount(name string) (int, error) {
+	for idx, account := range c.Accounts {
+		if account.Name == name {
+			return idx, nil
+		}
+	}
+
+	return -1, fmt.Errorf("%w: %s", ErrAccountNotFound, name)
 }
 
 func (c *Config) ListAccountsFiltered(filter []string) []clusters.ClusterAccount {
@@ -116,7 +424,7 @@ func (c *Config) ListAccountsFiltered(filter []string) []clusters.ClusterAccount
 
 	for _, account := range c.Accounts {
 		if _, ok := filterAccounts[account.Name]; ok {
-			accounts = append(accounts, account)
+			accounts = append(accounts, c.WithDefaults(account))
 			delete(filterAccounts, account.Name)
 		}
 	}
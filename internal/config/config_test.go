@@ -0,0 +1,88 @@
+package config_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/config"
+)
+
+func TestAccountDefaults(t *testing.T) {
+	t.Parallel()
+
+	file, err := config.Parse([]byte(`
+apiVersion: gogok8s/v1
+defaults:
+  regions: [us-east-1, us-west-2]
+  format: "${name}.${clusterName}"
+  extraUsers:
+    - name: admin
+      profile: admin
+accounts:
+  - name: Dev
+    profile: dev
+  - name: Staging
+    profile: staging
+    additionalRegions: [eu-west-1]
+  - name: Prod
+    profile: prod
+    regions: [eu-west-1]
+    format: "prod.${clusterName}"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := file.Config
+	if err = cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{
+		"Dev":     {"us-east-1", "us-west-2"},
+		"Staging": {"us-east-1", "us-west-2", "eu-west-1"},
+		"Prod":    {"eu-west-1"},
+	}
+
+	for _, account := range cfg.ListAccounts() {
+		if !reflect.DeepEqual(account.Regions, expected[account.Name]) {
+			t.Errorf("expected %s to scan %v, got %v", account.Name, expected[account.Name], account.Regions)
+		}
+
+		if len(account.ExtraUsers) != 1 {
+			t.Errorf("expected %s to inherit the default extra users, got %v", account.Name, account.ExtraUsers)
+		}
+	}
+
+	prod, _ := cfg.GetAccount("Prod")
+	if prod.Format != "prod.${clusterName}" {
+		t.Errorf("expected Prod to override the default format, got %s", prod.Format)
+	}
+
+	// Settings matching the defaults are left unset, so the account keeps following them
+	staging, _ := cfg.GetAccount("Staging")
+	stripped := cfg.WithoutDefaults(staging)
+
+	want := clusters.EKSAccount{Name: "Staging", Profile: "staging", AdditionalRegions: []string{"eu-west-1"}}
+	if !reflect.DeepEqual(stripped, want) {
+		t.Errorf("expected %+v, got %+v", want, stripped)
+	}
+}
+
+func TestAccountDefaultsValidate(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.NewConfig()
+	cfg.Accounts = []clusters.EKSAccount{{Name: "Dev", Profile: "dev"}}
+
+	if err := cfg.Validate(); !errors.Is(err, config.ErrMustContainAWSRegion) {
+		t.Errorf("expected ErrMustContainAWSRegion without default regions, got %v", err)
+	}
+
+	cfg.Defaults.Regions = []string{"us-east-9"}
+	if err := cfg.Validate(); !errors.Is(err, config.ErrInvalidAWSRegion) {
+		t.Errorf("expected ErrInvalidAWSRegion for an invalid default region, got %v", err)
+	}
+}
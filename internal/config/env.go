@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+)
+
+var ErrEnvNotSet = errors.New("environment variable isn't set")
+
+// envPattern matches ${env:VAR} and ${env:VAR:-default}.
+//
+//nolint:gochecknoglobals
+var envPattern = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces the environment variables within every string of raw in place. Strings using a variable that
+// isn't set and has no default are left as they are, and recorded in unset by their path.
+func expandEnv(raw any, path string, unset map[string]error) any {
+	switch value := raw.(type) {
+	case string:
+		expanded, err := expandString(value)
+		if err != nil {
+			unset[path] = err
+
+			return value
+		}
+
+		return expanded
+	case map[string]any:
+		for key, child := range value {
+			value[key] = expandEnv(child, joinPath(path, key), unset)
+		}
+	case []any:
+		for idx, item := range value {
+			itemPath := fmt.Sprintf("%s[%d]", path, idx)
+			if named, ok := item.(map[string]any); ok && named["name"] != nil {
+				itemPath = fmt.Sprintf("%s[%v]", path, named["name"])
+			}
+
+			value[idx] = expandEnv(item, itemPath, unset)
+		}
+	}
+
+	return raw
+}
+
+// CheckAccountEnv returns an error, located within its file, when a setting of the account or a default it may inherit
+// uses an environment variable that isn't set.
+func (c *Config) CheckAccountEnv(name string) error {
+	prefix := fmt.Sprintf("accounts[%s]", name)
+
+	for _, path := range slices.Sorted(maps.Keys(c.unsetEnv)) {
+		if strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, "defaults.") {
+			problem := Problem{Severity: SeverityError, Path: path, File: c.SourceFile(path), Err: c.unsetEnv[path]}
+
+			return locateProblems([]Problem{problem})[0]
+		}
+	}
+
+	return nil
+}
+
+// expandString replaces ${env:VAR} with the value of VAR, and ${env:VAR:-default} with default when VAR is unset or
+// empty, like a shell.
+func expandString(value string) (string, error) {
+	var missing []string
+
+	expanded := envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		groups := envPattern.FindStringSubmatch(match)
+		hasDefault := len(match) > len("${env:"+groups[1]+"}")
+
+		if env := os.Getenv(groups[1]); env != "" {
+			return env
+		} else if hasDefault {
+			return groups[2]
+		}
+
+		missing = append(missing, groups[1])
+
+		return match
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %s", ErrEnvNotSet, missing[0])
+	}
+
+	return expanded, nil
+}
+
+// keepTemplates restores the templates of the original account whose expanded values weren't changed, so that writing
+// an account doesn't replace them with the values of the current environment.
+func keepTemplates(account, original clusters.EKSAccount) clusters.EKSAccount {
+	account.Profile = keepTemplate(account.Profile, original.Profile)
+	account.Format = keepTemplate(account.Format, original.Format)
+
+	account.ExtraUsers = slices.Clone(account.ExtraUsers)
+	for idx, user := range account.ExtraUsers {
+		for _, originalUser := range original.ExtraUsers {
+			if originalUser.Name == user.Name {
+				account.ExtraUsers[idx].Profile = keepTemplate(user.Profile, originalUser.Profile)
+			}
+		}
+	}
+
+	return account
+}
+
+func keepTemplate(value, template string) string {
+	if expanded, err := expandString(template); err == nil && expanded == value {
+		return template
+	}
+
+	return value
+}
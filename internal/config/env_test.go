@@ -0,0 +1,104 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+)
+
+// The environment is shared between tests, so these tests can't run in parallel.
+
+func TestLoadExpandsEnv(t *testing.T) {
+	t.Setenv("GOGOK8S_TEST_PROFILE_PREFIX", "ci")
+
+	dir := writeConfigFiles(t, map[string]string{
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+accounts:
+  - name: Prod
+    profile: ${env:GOGOK8S_TEST_PROFILE_PREFIX}-prod-${region}
+    regions: [us-east-1]
+    format: ${env:GOGOK8S_TEST_UNSET:-prod}.${region}.${clusterName}
+`,
+	})
+	filename := filepath.Join(dir, ".gogok8s.yaml")
+
+	file, err := config.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := file.Config.GetAccount("Prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prod.Profile != "ci-prod-${region}" || prod.Format != "prod.${region}.${clusterName}" {
+		t.Fatalf("expected the environment variables to be expanded, got %+v", prod)
+	}
+
+	// Writing the account back keeps the templates whose values didn't change
+	prod.Regions = append(prod.Regions, "us-west-2")
+	if err = file.Config.UpdateAccount(prod); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = file.Config.WriteToFile(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), "profile: ${env:GOGOK8S_TEST_PROFILE_PREFIX}-prod-${region}") {
+		t.Fatalf("expected the profile template to be kept, got:\n%s", data)
+	}
+}
+
+func TestLoadUnsetEnv(t *testing.T) {
+	dir := writeConfigFiles(t, map[string]string{
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+accounts:
+  - name: Prod
+    profile: ${env:GOGOK8S_TEST_UNSET}
+    regions: [us-east-1]
+  - name: Dev
+    profile: dev
+    regions: [us-east-1]
+`,
+	})
+	filename := filepath.Join(dir, ".gogok8s.yaml")
+
+	// The config still loads, only the accounts using the variable can't be used
+	file, err := config.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = file.Config.Validate(); err != nil {
+		t.Fatalf("expected the config to be usable, got %v", err)
+	}
+
+	problems := file.Problems()
+	if len(problems) != 1 || problems[0].Severity != config.SeverityWarning ||
+		!errors.Is(problems[0], config.ErrEnvNotSet) || problems[0].Path != "accounts[Prod].profile" ||
+		problems[0].Line != 5 {
+		t.Fatalf("expected a single warning about the profile of Prod, got %v", problems)
+	}
+
+	if _, err = file.Config.SelectAccounts(config.AccountSelector{Accounts: []string{"Dev"}}); err != nil {
+		t.Errorf("expected Dev to be selectable, got %v", err)
+	}
+
+	_, err = file.Config.SelectAccounts(config.AccountSelector{})
+	if !errors.Is(err, config.ErrEnvNotSet) || !strings.HasPrefix(err.Error(), filename+":5:") {
+		t.Fatalf("expected selecting Prod to fail with the position of its profile, got %v", err)
+	}
+}
@@ -0,0 +1,377 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/BigPapaChas/gogok8s/internal/source"
+)
+
+// LocalFilename is the name of the optional overlay read from the current directory, which takes precedence over the
+// config file and everything it includes.
+const LocalFilename = ".gogok8s.local.yaml"
+
+var (
+	ErrIncludeCycle     = errors.New("config files include each other")
+	ErrAccountIncluded  = errors.New("account is defined in another config file")
+	ErrIncludeNotExists = errors.New("included config file doesn't exist")
+)
+
+// Load reads the config file and merges it with its source, the files it includes and the .gogok8s.local.yaml of the
+// current directory, migrating older layouts to CurrentAPIVersion. Files are merged in order of precedence:
+//
+//  1. the source of the config file, as last accepted by `gogok8s config pull`
+//  2. the files listed in include, in the order they are listed, each after the files it includes itself
+//  3. the config file
+//  4. .gogok8s.local.yaml in the current directory
+//
+// Every setting of a file overrides the same setting of the files before it. Accounts and extra users are merged by
+// name, so a file can override single fields of an account defined in another file, while other lists are replaced.
+// Once merged, ${env:VAR} and ${env:VAR:-default} are replaced with the environment variable VAR. Settings using a
+// variable that isn't set are left as they are, reported by Problems and by CheckAccountEnv.
+func Load(filename string) (*File, error) {
+	// Without a cache directory the source is left out, which LoadWithCache warns about
+	cache, _ := source.DefaultCache()
+
+	// Without a current directory there is no local overlay to merge
+	cwd, _ := os.Getwd()
+
+	return LoadWithCache(filename, cwd, cache)
+}
+
+// LoadWithCache is Load, reading the .gogok8s.local.yaml of localDir rather than the current directory and the contents
+// of the source from cache. An empty localDir leaves the overlay out, and a nil cache the source.
+func LoadWithCache(filename, localDir string, cache *source.Cache) (*File, error) {
+	loader := &layerLoader{
+		merged:  make(map[string]any),
+		sources: make(map[string]string),
+		loaded:  make(map[string]bool),
+		cache:   cache,
+	}
+
+	main, err := loader.load(filename, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if localDir != "" {
+		local := filepath.Join(localDir, LocalFilename)
+		if _, err = os.Stat(local); err == nil {
+			if _, err = loader.load(local, nil, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	delete(loader.merged, "include")
+
+	// Only the merged config is expanded, the config file itself keeps its templates for when it's written back.
+	// Settings using variables that aren't set only fail the commands that use them
+	unsetEnv := make(map[string]error)
+	expandEnv(loader.merged, "", unsetEnv)
+
+	cfg, err := decode(loader.merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
+	}
+
+	cfg.own = main.Config
+	cfg.filename = main.Filename
+	cfg.sources = loader.sources
+	cfg.unsetEnv = unsetEnv
+
+	return &File{
+		Filename:      main.Filename,
+		Config:        cfg,
+		APIVersion:    main.APIVersion,
+		UnknownFields: main.UnknownFields,
+		Layers:        loader.layers,
+		Warnings:      loader.warnings,
+	}, nil
+}
+
+type layerLoader struct {
+	merged  map[string]any
+	sources map[string]string
+	loaded  map[string]bool
+	layers  []*File
+
+	cache    *source.Cache
+	warnings []string
+}
+
+// load merges the files included by filename and then filename itself. stack holds the files currently being loaded,
+// to catch files that include each other. Only the config file itself can have a source, which is merged first.
+func (l *layerLoader) load(filename string, stack []string, main bool) (*File, error) {
+	filename, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config file path: %w", err)
+	}
+
+	for _, loading := range stack {
+		if loading == filename {
+			return nil, fmt.Errorf("%w: %s", ErrIncludeCycle, strings.Join(append(stack, filename), " -> "))
+		}
+	}
+
+	file, raw, err := loadRaw(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.Config.Source != nil && main {
+		if err = l.loadSource(file.Config.Source); err != nil {
+			return nil, err
+		}
+	} else if file.Config.Source != nil {
+		l.warnings = append(l.warnings, fmt.Sprintf("source in %s is ignored, only the config file itself can have a "+
+			"source", filename))
+
+		delete(raw, "source")
+	}
+
+	for _, pattern := range file.Config.Include {
+		includes, err := resolveInclude(filepath.Dir(filename), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+
+		for _, include := range includes {
+			if l.loaded[include] {
+				continue
+			}
+
+			if _, err = l.load(include, append(stack, filename), false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	l.loaded[filename] = true
+	l.layers = append(l.layers, file)
+	mergeMaps(l.merged, raw, "", filename, l.sources)
+
+	return file, nil
+}
+
+// loadSource merges the cached contents of a source when they match its pin. A source that hasn't been pulled, or whose
+// cached contents don't match, is left out with a warning rather than failing, so `gogok8s config pull` can still run.
+func (l *layerLoader) loadSource(src *SourceConfig) error {
+	if l.cache == nil {
+		l.warnings = append(l.warnings, fmt.Sprintf("source %s is ignored, there's no cache directory to read it from",
+			src.URL))
+
+		return nil
+	}
+
+	data, err := l.cache.Read(src.URL)
+	if err != nil && errors.Is(err, source.ErrNotCached) {
+		l.warnings = append(l.warnings, fmt.Sprintf("source %s hasn't been pulled yet, run `gogok8s config pull`",
+			src.URL))
+
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if source.Checksum(data) != src.SHA256 {
+		l.warnings = append(l.warnings, fmt.Sprintf("source %s is ignored, its cached contents don't match the pinned "+
+			"sha256, run `gogok8s config pull` to review them", src.URL))
+
+		return nil
+	}
+
+	raw, version, err := parseRaw(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src.URL, err)
+	}
+
+	// Paths within a fragment would be relative to wherever it was fetched from, so it can't refer to other files
+	delete(raw, "include")
+	delete(raw, "source")
+
+	cfg, err := decode(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", src.URL, err)
+	}
+
+	l.layers = append(l.layers, &File{
+		Filename:      src.URL,
+		Config:        cfg,
+		APIVersion:    version,
+		UnknownFields: unknownFields(raw, reflect.TypeOf(Config{}), ""),
+	})
+	mergeMaps(l.merged, raw, "", src.URL, l.sources)
+
+	return nil
+}
+
+// resolveInclude returns the files matching an include pattern, relative to the directory of the including file. A
+// pattern without wildcards has to match an existing file, while a glob may match nothing.
+func resolveInclude(dir, pattern string) ([]string, error) {
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user home directory: %w", err)
+		}
+
+		pattern = filepath.Join(home, rest)
+	}
+
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+	}
+
+	if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+		return nil, fmt.Errorf("%w: %s", ErrIncludeNotExists, pattern)
+	}
+
+	return matches, nil
+}
+
+// mergeMaps merges src into dst, recording the file every merged setting came from under its path, e.g.
+// accounts[Dev].regions.
+func mergeMaps(dst, src map[string]any, path, file string, sources map[string]string) {
+	for key, value := range src {
+		keyPath := joinPath(path, key)
+
+		switch existing := dst[key].(type) {
+		case map[string]any:
+			if overlay, ok := value.(map[string]any); ok {
+				mergeMaps(existing, overlay, keyPath, file, sources)
+
+				continue
+			}
+		case []any:
+			if overlay, ok := value.([]any); ok && isNamedList(existing) && isNamedList(overlay) {
+				dst[key] = mergeNamedLists(existing, overlay, keyPath, file, sources)
+
+				continue
+			}
+		}
+
+		dst[key] = value
+		recordSources(value, keyPath, file, sources)
+	}
+}
+
+// mergeNamedLists merges the items of src into the items of dst with the same name, appending the rest. Items are only
+// matched against dst as it was before merging, and only the first item of a name within src is merged, so duplicate
+// names within one file are kept for Validate to report.
+func mergeNamedLists(dst, src []any, path, file string, sources map[string]string) []any {
+	existing := len(dst)
+	seen := make(map[string]bool)
+
+	for _, item := range src {
+		overlay, _ := item.(map[string]any)
+		name := fmt.Sprint(overlay["name"])
+		itemPath := fmt.Sprintf("%s[%s]", path, name)
+		merged := false
+
+		for _, candidate := range dst[:existing] {
+			base, _ := candidate.(map[string]any)
+			if !seen[name] && base["name"] == overlay["name"] {
+				mergeMaps(base, overlay, itemPath, file, sources)
+
+				merged = true
+
+				break
+			}
+		}
+
+		seen[name] = true
+
+		if !merged {
+			dst = append(dst, overlay)
+			recordSources(overlay, itemPath, file, sources)
+		}
+	}
+
+	return dst
+}
+
+// isNamedList reports whether every item of the list is a map with a name, like accounts and extraUsers.
+func isNamedList(list []any) bool {
+	for _, item := range list {
+		value, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		if _, ok = value["name"]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func recordSources(value any, path, file string, sources map[string]string) {
+	sources[path] = file
+
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			recordSources(child, joinPath(path, key), file, sources)
+		}
+	case []any:
+		if !isNamedList(value) {
+			return
+		}
+
+		for _, item := range value {
+			recordSources(item, fmt.Sprintf("%s[%v]", path, item.(map[string]any)["name"]), file, sources)
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// SourceFile returns the file the setting at path came from, falling back to the file of its closest parent, or an empty
+// string when the config wasn't read by Load.
+func (c *Config) SourceFile(path string) string {
+	for path != "" {
+		if file, ok := c.sources[path]; ok {
+			return file
+		}
+
+		if strings.HasSuffix(path, "]") {
+			path = path[:strings.LastIndex(path, "[")]
+		} else if idx := strings.LastIndex(path, "."); idx >= 0 {
+			path = path[:idx]
+		} else {
+			path = ""
+		}
+	}
+
+	return ""
+}
+
+// checkAccountDefined returns an error when the account is defined by a file other than the config file itself,
+// since only the config file is ever written.
+func (c *Config) checkAccountDefined(name string) error {
+	if c.own == nil {
+		return nil
+	}
+
+	if file := c.SourceFile(fmt.Sprintf("accounts[%s]", name)); file != "" && file != c.filename {
+		return fmt.Errorf("%w: %s is defined in %s, which gogok8s doesn't modify", ErrAccountIncluded, name, file)
+	}
+
+	return nil
+}
@@ -0,0 +1,330 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/config"
+	"github.com/BigPapaChas/gogok8s/internal/source"
+)
+
+func writeConfigFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadMergesIncludes(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"team/accounts.yaml": `
+apiVersion: gogok8s/v1
+onConflict: rename
+accounts:
+  - name: Dev
+    profile: dev
+    regions: [us-east-1, us-west-2]
+    format: ""
+  - name: Prod
+    profile: prod
+    regions: [us-east-1]
+    format: ""
+    extraUsers:
+      - name: admin
+        profile: prod-admin
+`,
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+include: [team/*.yaml]
+accounts:
+  - name: Prod
+    regions: [eu-west-1]
+    extraUsers:
+      - name: admin
+        profile: my-prod-admin
+      - name: readonly
+        profile: my-prod-readonly
+  - name: Sandbox
+    profile: sandbox
+    regions: [us-east-2]
+`,
+	})
+
+	file, err := config.Load(filepath.Join(dir, ".gogok8s.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := file.Config
+	if cfg.OnConflict != "rename" || len(cfg.Accounts) != 3 || len(file.Layers) != 2 {
+		t.Fatalf("expected the included file to be merged, got %+v", cfg)
+	}
+
+	prod, _ := cfg.GetAccount("Prod")
+	if prod.Profile != "prod" || !reflect.DeepEqual(prod.Regions, []string{"eu-west-1"}) {
+		t.Errorf("expected the regions of Prod to be overridden and its profile kept, got %+v", prod)
+	}
+
+	if len(prod.ExtraUsers) != 2 || prod.ExtraUsers[0].Profile != "my-prod-admin" {
+		t.Errorf("expected the extra users of Prod to be merged by name, got %+v", prod.ExtraUsers)
+	}
+
+	team := filepath.Join(dir, "team", "accounts.yaml")
+	if source := cfg.SourceFile("accounts[Prod].profile"); source != team {
+		t.Errorf("expected the profile of Prod to come from %s, got %s", team, source)
+	}
+
+	if err = cfg.RemoveAccount("Dev"); !errors.Is(err, config.ErrAccountIncluded) {
+		t.Errorf("expected ErrAccountIncluded removing an included account, got %v", err)
+	}
+
+	// Only the config file itself is written back, still including the team file
+	out := filepath.Join(dir, "written.yaml")
+	if err = cfg.WriteToFile(out); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := config.LoadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(written.Config.Accounts) != 2 || len(written.Config.Include) != 1 {
+		t.Errorf("expected only the accounts of the config file to be written, got %+v", written.Config)
+	}
+}
+
+func TestLoadValidateReportsSource(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"team.yaml": "accounts:\n  - name: Dev\n    profile: dev\n    regions: [us-east-9]\n",
+		"main.yaml": "include: [team.yaml]\n",
+	})
+
+	file, err := config.Load(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = file.Config.Validate()
+	if !errors.Is(err, config.ErrInvalidAWSRegion) || !strings.HasPrefix(err.Error(), filepath.Join(dir, "team.yaml")) {
+		t.Errorf("expected an invalid region error from team.yaml, got %v", err)
+	}
+}
+
+func TestLoadIncludeErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"a.yaml":       "include: [b.yaml]\n",
+		"b.yaml":       "include: [a.yaml]\n",
+		"missing.yaml": "include: [nope.yaml]\n",
+	})
+
+	if _, err := config.Load(filepath.Join(dir, "a.yaml")); !errors.Is(err, config.ErrIncludeCycle) {
+		t.Errorf("expected ErrIncludeCycle, got %v", err)
+	}
+
+	if _, err := config.Load(filepath.Join(dir, "missing.yaml")); !errors.Is(err, config.ErrIncludeNotExists) {
+		t.Errorf("expected ErrIncludeNotExists, got %v", err)
+	}
+}
+
+func TestLoadMergesPinnedSource(t *testing.T) {
+	t.Parallel()
+
+	fragment := []byte(`
+apiVersion: gogok8s/v1
+accounts:
+  - name: Prod
+    profile: prod
+    regions: [us-east-1]
+`)
+	location := "https://example.com/team.yaml"
+
+	cache := &source.Cache{Dir: t.TempDir()}
+	if err := cache.Write(location, fragment); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := writeConfigFiles(t, map[string]string{
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+source:
+  url: ` + location + `
+  sha256: ` + source.Checksum(fragment) + `
+accounts:
+  - name: Prod
+    profile: my-prod
+`,
+	})
+
+	file, err := config.LoadWithCache(filepath.Join(dir, ".gogok8s.yaml"), "", cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prod, err := file.Config.GetAccount("Prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if prod.Profile != "my-prod" || !reflect.DeepEqual(prod.Regions, []string{"us-east-1"}) {
+		t.Fatalf("expected the config file to override the source, got %+v", prod)
+	}
+
+	if len(file.Layers) != 2 || file.Layers[0].Filename != location || len(file.Warnings) != 0 {
+		t.Fatalf("expected the source to be merged first, got %+v", file)
+	}
+
+	// Cached contents that don't match the pin are left out
+	if err = cache.Write(location, []byte("accounts: []\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = config.LoadWithCache(filepath.Join(dir, ".gogok8s.yaml"), "", cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(file.Layers) != 1 || len(file.Warnings) != 1 {
+		t.Fatalf("expected the modified source to be ignored with a warning, got %+v", file)
+	}
+}
+
+func TestLoadMergesLocalOverlay(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+accounts:
+  - name: Dev
+    profile: dev
+    regions: [us-east-1]
+`,
+		"project/" + config.LocalFilename: `
+accounts:
+  - name: Dev
+    profile: dev-project
+`,
+	})
+
+	file, err := config.LoadWithCache(filepath.Join(dir, ".gogok8s.yaml"), filepath.Join(dir, "project"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := file.Config.GetAccount("Dev")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if dev.Profile != "dev-project" || !reflect.DeepEqual(dev.Regions, []string{"us-east-1"}) {
+		t.Errorf("expected the local overlay to override the profile of Dev, got %+v", dev)
+	}
+
+	local := filepath.Join(dir, "project", config.LocalFilename)
+	if source := file.Config.SourceFile("accounts[Dev].profile"); source != local {
+		t.Errorf("expected the profile of Dev to come from %s, got %s", local, source)
+	}
+}
+
+func TestLoadReportsDuplicatesWithinFile(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"team.yaml": "accounts:\n  - name: Dev\n    profile: dev\n    regions: [us-east-1]\n",
+		"main.yaml": `include: [team.yaml]
+accounts:
+  - name: Dev
+    profile: dev-main
+  - name: Dev
+    profile: dev-other
+`,
+	})
+
+	file, err := config.Load(filepath.Join(dir, "main.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = file.Config.Validate(); !errors.Is(err, config.ErrDuplicateAccountName) ||
+		!strings.HasPrefix(err.Error(), filepath.Join(dir, "main.yaml")+":5:") {
+		t.Errorf("expected a duplicate account error at main.yaml:5, got %v", err)
+	}
+}
+
+func TestUpdateAccountKeepsOverlaysOut(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"team.yaml": "accounts:\n  - name: Prod\n    profile: prod\n    regions: [us-east-1]\n",
+		".gogok8s.yaml": `
+apiVersion: gogok8s/v1
+include: [team.yaml]
+accounts:
+  - name: Dev
+    profile: dev
+    regions: [us-east-1]
+`,
+		"project/" + config.LocalFilename: "accounts:\n  - name: Dev\n    profile: dev-project\n",
+	})
+
+	file, err := config.LoadWithCache(filepath.Join(dir, ".gogok8s.yaml"), filepath.Join(dir, "project"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := file.Config
+
+	for _, name := range []string{"Dev", "Prod"} {
+		account, err := cfg.GetAccount(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		account.Regions = []string{"eu-west-1"}
+		if err = cfg.UpdateAccount(account); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(dir, "written.yaml")
+	if err = cfg.WriteToFile(out); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := config.LoadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []clusters.EKSAccount{
+		{Name: "Dev", Profile: "dev", Regions: []string{"eu-west-1"}},
+		{Name: "Prod", Regions: []string{"eu-west-1"}},
+	}
+	if !reflect.DeepEqual(written.Config.Accounts, expected) {
+		t.Errorf("expected only the edited regions to be written, got %+v", written.Config.Accounts)
+	}
+}
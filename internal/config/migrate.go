@@ -0,0 +1,333 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+// CurrentAPIVersion is the apiVersion written to new config files, and the version older files are migrated to.
+const CurrentAPIVersion = "gogok8s/v1"
+
+var (
+	ErrUnsupportedAPIVersion = errors.New("unsupported config apiVersion")
+	ErrInvalidConfig         = errors.New("invalid config file")
+)
+
+// migration upgrades the raw contents of a config file from one apiVersion to the next.
+type migration struct {
+	from    string
+	to      string
+	migrate func(raw map[string]any) error
+}
+
+// The migrations are applied in order, starting with the one whose from matches the apiVersion of the file. Files
+// written before apiVersion existed have no apiVersion, which is the from of the first migration.
+//
+//nolint:gochecknoglobals
+var migrations = []migration{
+	{from: "", to: "gogok8s/v1", migrate: migrateToV1},
+}
+
+// File is a config file as read from disk, migrated to CurrentAPIVersion in memory.
+type File struct {
+	Filename string
+	Config   *Config
+	// The apiVersion the file was written with, empty when it was written before apiVersion was introduced.
+	APIVersion string
+	// The paths of fields that aren't part of the config, e.g. accounts[0].regoins. They are dropped when the config
+	// is written back.
+	UnknownFields []string
+	// The files merged into Config by Load, from the lowest to the highest precedence, including this one.
+	Layers []*File
+	// Problems Load worked around, such as a source that hasn't been pulled yet.
+	Warnings []string
+}
+
+// NeedsMigration reports whether the file on disk was written with an older apiVersion.
+func (f *File) NeedsMigration() bool {
+	return f.APIVersion != CurrentAPIVersion
+}
+
+// LoadFile reads a single config file, without the files it includes, migrating older layouts to CurrentAPIVersion.
+func LoadFile(filename string) (*File, error) {
+	file, _, err := loadRaw(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// Parse parses the contents of a single config file, migrating older layouts to CurrentAPIVersion.
+func Parse(data []byte) (*File, error) {
+	raw, version, err := parseRaw(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := decode(raw)
+	if err != nil {
+		return nil, decodeError(data, err)
+	}
+
+	return &File{
+		Config:        cfg,
+		APIVersion:    version,
+		UnknownFields: unknownFields(raw, reflect.TypeOf(Config{}), ""),
+	}, nil
+}
+
+// loadRaw reads and parses a config file, also returning its migrated contents.
+func loadRaw(filename string) (*File, map[string]any, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw, version, err := parseRaw(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	cfg, err := decode(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", filename, decodeError(data, err))
+	}
+
+	return &File{
+		Filename:      filename,
+		Config:        cfg,
+		APIVersion:    version,
+		UnknownFields: unknownFields(raw, reflect.TypeOf(Config{}), ""),
+	}, raw, nil
+}
+
+func parseRaw(data []byte) (map[string]any, string, error) {
+	raw := make(map[string]any)
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, "", fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	// yaml.Unmarshal leaves the map nil for an empty file
+	if raw == nil {
+		raw = make(map[string]any)
+	}
+
+	version, err := Migrate(raw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return raw, version, nil
+}
+
+func decode(raw map[string]any) (*Config, error) {
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+
+	cfg := NewConfig()
+	if err = yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	return cfg, nil
+}
+
+// decodeError returns the error of decoding the file as it was written when there is one, since decode reads the
+// migrated contents re-encoded, whose line numbers don't match the file.
+func decodeError(data []byte, err error) error {
+	if direct := yaml.Unmarshal(data, NewConfig()); direct != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, direct)
+	}
+
+	return err
+}
+
+// Migrate upgrades the raw contents of a config file to CurrentAPIVersion in place, returning the apiVersion it was
+// written with.
+func Migrate(raw map[string]any) (string, error) {
+	version, ok := raw["apiVersion"].(string)
+	if _, exists := raw["apiVersion"]; exists && !ok {
+		return "", fmt.Errorf("%w: apiVersion must be a string", ErrInvalidConfig)
+	}
+
+	current := version
+
+	for _, m := range migrations {
+		if m.from != current {
+			continue
+		}
+
+		if err := m.migrate(raw); err != nil {
+			return "", fmt.Errorf("failed to migrate config from apiVersion %q to %s: %w", m.from, m.to, err)
+		}
+
+		raw["apiVersion"] = m.to
+		current = m.to
+	}
+
+	if current != CurrentAPIVersion {
+		return "", fmt.Errorf("%w %s, this version of gogok8s supports up to %s", ErrUnsupportedAPIVersion, version,
+			CurrentAPIVersion)
+	}
+
+	return version, nil
+}
+
+// migrateToV1 renames keys to their canonical spelling. Files without an apiVersion were read case-insensitively, so
+// e.g. `extrausers` or `OnConflict` worked, while the current config is read case-sensitively.
+func migrateToV1(raw map[string]any) error {
+	canonicalizeKeys(raw, reflect.TypeOf(Config{}))
+
+	return nil
+}
+
+func canonicalizeKeys(raw any, typ reflect.Type) {
+	switch value := raw.(type) {
+	case map[string]any:
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			return
+		}
+
+		fields := yamlFields(typ)
+		keys := make([]string, 0, len(value))
+
+		for key := range value {
+			keys = append(keys, key)
+		}
+
+		for _, key := range keys {
+			for name, fieldType := range fields {
+				if !strings.EqualFold(key, name) {
+					continue
+				}
+
+				canonicalizeKeys(value[key], fieldType)
+
+				if _, exists := value[name]; !exists {
+					value[name] = value[key]
+					delete(value, key)
+				}
+			}
+		}
+	case []any:
+		if typ.Kind() != reflect.Slice {
+			return
+		}
+
+		for _, item := range value {
+			canonicalizeKeys(item, typ.Elem())
+		}
+	}
+}
+
+// unknownFields returns the paths of the keys within raw that don't match a field of typ, sorted.
+func unknownFields(raw any, typ reflect.Type, path string) []string {
+	var unknown []string
+
+	switch value := raw.(type) {
+	case map[string]any:
+		// Optional sections such as source are pointers to their struct
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+
+		fields := yamlFields(typ)
+
+		for key, child := range value {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+
+			fieldType, ok := fields[key]
+			if !ok {
+				unknown = append(unknown, fieldPath)
+
+				continue
+			}
+
+			unknown = append(unknown, unknownFields(child, fieldType, fieldPath)...)
+		}
+	case []any:
+		if typ.Kind() != reflect.Slice {
+			return nil
+		}
+
+		for idx, item := range value {
+			unknown = append(unknown, unknownFields(item, typ.Elem(), fmt.Sprintf("%s[%d]", path, idx))...)
+		}
+	}
+
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+// yamlFields maps the yaml keys of a struct to the types of their fields.
+func yamlFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, typ.NumField())
+
+	for idx := 0; idx < typ.NumField(); idx++ {
+		field := typ.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fields[name] = field.Type
+	}
+
+	return fields
+}
+
+// BackupFile copies the config file next to itself with a timestamp suffix, returning the name of the copy. An existing
+// backup is never overwritten.
+func BackupFile(filename string) (string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	// Like kubeconfig backups, a second migration within the same second gets a backup of its own
+	backup, _, err := kubecfg.WriteTimestamped(func(timestamp string) string {
+		return fmt.Sprintf("%s.%s.bak", filename, timestamp)
+	}, data, info.Mode().Perm(), time.Now().UTC())
+	if err != nil {
+		return "", fmt.Errorf("failed to write config backup: %w", err)
+	}
+
+	return backup, nil
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+)
+
+func TestParseMigratesUnversionedConfig(t *testing.T) {
+	t.Parallel()
+
+	file, err := config.Parse([]byte(`
+OnConflict: rename
+accounts:
+  - name: Dev
+    Profile: dev
+    regions: [us-east-1]
+    extrausers:
+      - name: admin
+        profile: dev-admin
+    regoins: [us-west-2]
+backup:
+  retention: 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !file.NeedsMigration() || file.APIVersion != "" {
+		t.Errorf("expected the file to need migrating from no apiVersion, got %q", file.APIVersion)
+	}
+
+	cfg := file.Config
+	if cfg.APIVersion != config.CurrentAPIVersion {
+		t.Errorf("expected apiVersion %s, got %s", config.CurrentAPIVersion, cfg.APIVersion)
+	}
+
+	if cfg.OnConflict != "rename" || cfg.Accounts[0].Profile != "dev" || len(cfg.Accounts[0].ExtraUsers) != 1 {
+		t.Errorf("expected keys to be matched case-insensitively, got %+v", cfg)
+	}
+
+	expected := []string{"accounts[0].regoins", "backup"}
+	if !reflect.DeepEqual(file.UnknownFields, expected) {
+		t.Errorf("expected unknown fields %v, got %v", expected, file.UnknownFields)
+	}
+}
+
+func TestParseCurrentConfig(t *testing.T) {
+	t.Parallel()
+
+	file, err := config.Parse([]byte(`
+apiVersion: gogok8s/v1
+OnConflict: rename
+source:
+  url: https://example.com/team.yaml
+  sha: abc
+accounts: []
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.NeedsMigration() {
+		t.Error("expected a file at the current apiVersion to not need migrating")
+	}
+
+	// Only files without an apiVersion were read case-insensitively
+	expected := []string{"OnConflict", "source.sha"}
+	if file.Config.OnConflict != "" || !reflect.DeepEqual(file.UnknownFields, expected) {
+		t.Errorf("expected unknown fields %v, got %v", expected, file.UnknownFields)
+	}
+}
+
+func TestBackupFileKeepsEarlierBackups(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), ".gogok8s.yaml")
+	if err := os.WriteFile(filename, []byte("accounts: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := config.BackupFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := config.BackupFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Errorf("expected backups taken in quick succession to get different names, got %s twice", first)
+	}
+}
+
+func TestParseUnsupportedAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	if _, err := config.Parse([]byte("apiVersion: gogok8s/v99\n")); !errors.Is(err, config.ErrUnsupportedAPIVersion) {
+		t.Errorf("expected ErrUnsupportedAPIVersion, got %v", err)
+	}
+}
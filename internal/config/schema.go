@@ -0,0 +1,121 @@
+package config
+
+import (
+	"maps"
+	"reflect"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema returns a JSON Schema of the config file, which editors use to validate and complete it. It is generated from
+// the Config type, so it can't go out of date, with the allowed values and descriptions of settings added on top.
+func Schema() map[string]any {
+	schema := schemaFor(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = schemaDialect
+	schema["title"] = "gogok8s config"
+
+	return schema
+}
+
+// schemaFor returns the schema of typ, the type of the setting at path. Items of lists are written as path[] and
+// values of maps as path.*.
+func schemaFor(typ reflect.Type, path string) map[string]any {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	schema := make(map[string]any)
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for name, field := range yamlFields(typ) {
+			properties[name] = schemaFor(field, joinPath(path, name))
+		}
+
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = schemaFor(typ.Elem(), path+"[]")
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaFor(typ.Elem(), path+".*")
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Int:
+		schema["type"] = "integer"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	default:
+	}
+
+	maps.Copy(schema, schemaOverrides()[path])
+
+	return schema
+}
+
+func schemaOverrides() map[string]map[string]any {
+	regions := map[string]any{"enum": ValidRegions}
+	extraUser := map[string]any{"required": []string{"name", "profile"}}
+
+	return map[string]map[string]any{
+		"apiVersion": {
+			"enum":        []string{CurrentAPIVersion},
+			"description": "The layout version of the config file.",
+		},
+		"source": {
+			"required":    []string{"url"},
+			"description": "A config fragment merged beneath this file, fetched by `gogok8s config pull`.",
+		},
+		"source.url": {
+			"description": "An https URL, or a file within a local git checkout as <repo>//<path>[?ref=<ref>].",
+		},
+		"source.sha256": {
+			"pattern":     "^[0-9a-f]{64}$",
+			"description": "The SHA-256 of the accepted contents of the source.",
+		},
+		"include": {
+			"description": "Paths or globs of config files merged beneath this one, relative to its directory.",
+		},
+		"defaults": {
+			"description": "Settings inherited by every account that doesn't set them itself.",
+		},
+		"defaults.regions[]":    regions,
+		"defaults.extraUsers[]": extraUser,
+		"accounts[]": {
+			"required": []string{"name"},
+		},
+		"accounts[].profile": {
+			"description": "The AWS profile of the account, ${region} is replaced with the region being scanned.",
+		},
+		"accounts[].regions[]":           regions,
+		"accounts[].additionalRegions[]": regions,
+		"accounts[].format": {
+			"description": "The format of generated kubeconfig entry names, using ${name}, ${region}, ${clusterName} " +
+				"and ${clusterArn}.",
+		},
+		"accounts[].extraUsers[]": extraUser,
+		"groups": {
+			"description": "Named lists of accounts, selected with @name.",
+		},
+		"onConflict": {
+			"enum":        kubecfg.ConflictPolicies,
+			"description": "How sync handles entries whose names collide with kubeconfig entries gogok8s did not create.",
+		},
+		"onCollision": {
+			"enum":        kubecfg.CollisionPolicies,
+			"description": "How sync handles names generated for more than one cluster, user or context.",
+		},
+		"prompt.format": {
+			"description": "The format of `gogok8s prompt`, using ${account}, ${region}, ${cluster}, ${user}, " +
+				"${context} and ${namespace}.",
+		},
+		"prompt.color":           {"enum": terminal.Colors()},
+		"prompt.productionColor": {"enum": terminal.Colors()},
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+// GroupPrefix marks an account argument as the name of a group, e.g. @data-team.
+const GroupPrefix = "@"
+
+var (
+	ErrGroupNotFound        = errors.New("group not found")
+	ErrInvalidLabelSelector = errors.New("invalid label selector")
+	ErrInvalidLabel         = errors.New("invalid label")
+)
+
+// AccountSelector picks accounts by name, group and labels.
+type AccountSelector struct {
+	// Account names and @groups. Every account is selected when empty.
+	Accounts []string
+	// Label selectors that every selected account has to match, e.g. env=prod, env!=prod, team or !team.
+	Labels []string
+	// Account names and @groups that are never selected.
+	Exclude []string
+}
+
+// SelectAccounts returns the accounts picked by the selector with the defaults applied, in the order they're configured.
+// Account names that don't exist are warned about rather than failing, like ListAccountsFiltered, while a selected
+// account using an environment variable that isn't set is an error.
+func (c *Config) SelectAccounts(selector AccountSelector) ([]clusters.ClusterAccount, error) {
+	requirements, err := parseLabelSelectors(selector.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	included, err := c.expandAccountNames(selector.Accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := c.expandAccountNames(selector.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var accounts []clusters.ClusterAccount
+
+	for _, account := range c.ListAccounts() {
+		if _, ok := excluded[account.Name]; ok {
+			continue
+		}
+
+		if _, ok := included[account.Name]; len(selector.Accounts) > 0 && !ok {
+			continue
+		}
+
+		if !matchesLabels(account.Labels, requirements) {
+			continue
+		}
+
+		if err = c.CheckAccountEnv(account.Name); err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// expandAccountNames expands the groups within names to their accounts.
+func (c *Config) expandAccountNames(names []string) (map[string]struct{}, error) {
+	expanded := make(map[string]struct{})
+
+	for _, name := range names {
+		if group, ok := strings.CutPrefix(name, GroupPrefix); ok {
+			members, ok := c.Groups[group]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s", ErrGroupNotFound, group)
+			}
+
+			for _, member := range members {
+				expanded[member] = struct{}{}
+			}
+
+			continue
+		}
+
+		if _, err := c.findAccount(name); err != nil {
+			terminal.PrintWarning(fmt.Sprintf("can't find account `%s`", name))
+		}
+
+		expanded[name] = struct{}{}
+	}
+
+	return expanded, nil
+}
+
+// ListGroupNames returns the names of the groups, prefixed with GroupPrefix and sorted.
+func (c *Config) ListGroupNames() []string {
+	names := make([]string, 0, len(c.Groups))
+	for name := range c.Groups {
+		names = append(names, GroupPrefix+name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// ListLabels returns every key=value label of the accounts, sorted.
+func (c *Config) ListLabels() []string {
+	seen := make(map[string]struct{})
+
+	for _, account := range c.Accounts {
+		for key, value := range account.Labels {
+			seen[key+"="+value] = struct{}{}
+		}
+	}
+
+	labels := make([]string, 0, len(seen))
+	for label := range seen {
+		labels = append(labels, label)
+	}
+
+	sort.Strings(labels)
+
+	return labels
+}
+
+type labelRequirement struct {
+	key     string
+	value   string
+	negated bool
+	// Whether the requirement only checks that the key exists, e.g. `team` or `!team`.
+	exists bool
+}
+
+func parseLabelSelectors(selectors []string) ([]labelRequirement, error) {
+	var requirements []labelRequirement
+
+	for _, selector := range selectors {
+		for _, term := range strings.Split(selector, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+
+			requirement, err := parseLabelRequirement(term)
+			if err != nil {
+				return nil, err
+			}
+
+			requirements = append(requirements, requirement)
+		}
+	}
+
+	return requirements, nil
+}
+
+func parseLabelRequirement(term string) (labelRequirement, error) {
+	var requirement labelRequirement
+
+	switch {
+	case strings.Contains(term, "!="):
+		requirement.key, requirement.value, _ = strings.Cut(term, "!=")
+		requirement.negated = true
+	case strings.Contains(term, "=="):
+		requirement.key, requirement.value, _ = strings.Cut(term, "==")
+	case strings.Contains(term, "="):
+		requirement.key, requirement.value, _ = strings.Cut(term, "=")
+	case strings.HasPrefix(term, "!"):
+		requirement.key = strings.TrimPrefix(term, "!")
+		requirement.negated = true
+		requirement.exists = true
+	default:
+		requirement.key = term
+		requirement.exists = true
+	}
+
+	requirement.key = strings.TrimSpace(requirement.key)
+	requirement.value = strings.TrimSpace(requirement.value)
+
+	if validateLabel(requirement.key, requirement.value) != nil {
+		return labelRequirement{}, fmt.Errorf("%w: %s", ErrInvalidLabelSelector, term)
+	}
+
+	return requirement, nil
+}
+
+func matchesLabels(labels map[string]string, requirements []labelRequirement) bool {
+	for _, requirement := range requirements {
+		value, ok := labels[requirement.key]
+
+		var matches bool
+		if requirement.exists {
+			matches = ok
+		} else {
+			matches = ok && value == requirement.value
+		}
+
+		if matches == requirement.negated {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateLabel checks that a label can be written in a selector, so it can't be empty or contain operators.
+func validateLabel(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "=!, ") || strings.ContainsAny(value, "=!, ") {
+		return fmt.Errorf("%w: %s=%s", ErrInvalidLabel, key, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+)
+
+func TestSelectAccounts(t *testing.T) {
+	t.Parallel()
+
+	file, err := config.Parse([]byte(`
+apiVersion: gogok8s/v1
+defaults:
+  regions: [us-east-1]
+groups:
+  data-team: [DataDev, DataProd]
+accounts:
+  - name: Dev
+    labels: {env: dev}
+  - name: Staging
+    labels: {env: staging}
+  - name: Prod
+    labels: {env: prod}
+  - name: DataDev
+    labels: {env: dev, team: data}
+  - name: DataProd
+    labels: {env: prod, team: data}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := file.Config
+	if err = cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		selector config.AccountSelector
+		expected []string
+	}{
+		{"all", config.AccountSelector{}, []string{"Dev", "Staging", "Prod", "DataDev", "DataProd"}},
+		{"label", config.AccountSelector{Labels: []string{"env=prod"}}, []string{"Prod", "DataProd"}},
+		{"negated label", config.AccountSelector{Labels: []string{"env!=prod,!team"}}, []string{"Dev", "Staging"}},
+		{"group", config.AccountSelector{Accounts: []string{"@data-team", "Dev"}}, []string{"Dev", "DataDev", "DataProd"}},
+		{"exclude", config.AccountSelector{Labels: []string{"team"}, Exclude: []string{"DataDev"}}, []string{"DataProd"}},
+	}
+
+	for _, test := range tests {
+		accounts, err := cfg.SelectAccounts(test.selector)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		var names []string
+		for _, account := range accounts {
+			names = append(names, account.PrettyName())
+		}
+
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, names)
+		}
+	}
+
+	_, err = cfg.SelectAccounts(config.AccountSelector{Accounts: []string{"@nope"}})
+	if !errors.Is(err, config.ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+
+	_, err = cfg.SelectAccounts(config.AccountSelector{Labels: []string{"=prod"}})
+	if !errors.Is(err, config.ErrInvalidLabelSelector) {
+		t.Errorf("expected ErrInvalidLabelSelector, got %v", err)
+	}
+}
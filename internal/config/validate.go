@@ -0,0 +1,496 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/source"
+	"github.com/BigPapaChas/gogok8s/internal/terminal"
+)
+
+type Severity string
+
+const (
+	// SeverityError marks problems that stop gogok8s from using the config.
+	SeverityError Severity = "error"
+	// SeverityWarning marks problems that are likely mistakes, but don't stop gogok8s from using the config.
+	SeverityWarning Severity = "warning"
+)
+
+var (
+	ErrUnknownField           = errors.New("unknown field, it is ignored")
+	ErrEmptyProfile           = errors.New("profile is empty, the default AWS credentials are used")
+	ErrUnknownVariable        = errors.New("unknown template variable")
+	ErrDuplicateExtraUserName = errors.New("extra user with that name already exists")
+)
+
+//nolint:gochecknoglobals
+var (
+	templateVariablePattern = regexp.MustCompile(`\$\{[^}]*\}`)
+	profileVariables        = []string{clusters.RegionVariable}
+)
+
+// Problem is an issue with a single setting of the config.
+type Problem struct {
+	Severity Severity
+	// The path of the setting, e.g. accounts[Prod].regions[1].
+	Path string
+	// The file the setting came from and its position within it, when known.
+	File   string
+	Line   int
+	Column int
+	Err    error
+
+	// Which of the list items of the same name the setting belongs to, set for duplicate names.
+	occurrence int
+}
+
+func (p Problem) Error() string {
+	location := p.File
+	if location != "" && p.Line > 0 {
+		location = fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+	}
+
+	if location == "" {
+		return fmt.Sprintf("%s: %s", p.Path, p.Err)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", location, p.Path, p.Err)
+}
+
+func (p Problem) Unwrap() error {
+	return p.Err
+}
+
+// Validate returns the first problem of the config that stops it from being used, located within its file.
+func (c *Config) Validate() error {
+	for _, problem := range c.Problems() {
+		if problem.Severity == SeverityError {
+			return locateProblems([]Problem{problem})[0]
+		}
+	}
+
+	return nil
+}
+
+// Problems returns every problem of the config, in the order the settings are checked, without their position within
+// their files.
+func (c *Config) Problems() []Problem {
+	v := &validator{config: c}
+
+	// Settings using variables that aren't set only fail the commands that use them, see CheckAccountEnv
+	for _, path := range slices.Sorted(maps.Keys(c.unsetEnv)) {
+		v.problems = append(v.problems, v.problem(SeverityWarning, path, c.unsetEnv[path]))
+	}
+
+	if _, err := kubecfg.ParseConflictPolicy(c.OnConflict); err != nil {
+		v.error("onConflict", err)
+	}
+
+	if _, err := kubecfg.ParseCollisionPolicy(c.OnCollision); err != nil {
+		v.error("onCollision", err)
+	}
+
+	if err := terminal.ValidateColor(c.Prompt.Color); err != nil {
+		v.error("prompt.color", err)
+	}
+
+	if err := terminal.ValidateColor(c.Prompt.ProductionColor); err != nil {
+		v.error("prompt.productionColor", err)
+	}
+
+	v.variables("prompt.format", c.Prompt.Format, PromptVariables)
+
+	if c.Source != nil {
+		if _, err := source.ParseLocation(c.Source.URL); err != nil {
+			v.error("source.url", err)
+		}
+	}
+
+	v.regions("defaults.regions", c.Defaults.Regions, "in defaults")
+	v.variables("defaults.format", c.Defaults.Format, clusters.FormatVariables)
+	v.extraUsers("defaults.extraUsers", c.Defaults.ExtraUsers, "defaults")
+
+	accountNames := make(map[string]int)
+
+	for _, account := range c.Accounts {
+		path := fmt.Sprintf("accounts[%s]", account.Name)
+
+		// validate that there are no duplicate account names
+		if occurrences, ok := accountNames[account.Name]; ok {
+			v.error(path, duplicateAccountError(fmt.Sprintf("`%s`", account.Name)))
+			v.problems[len(v.problems)-1].occurrence = occurrences
+			accountNames[account.Name]++
+
+			continue
+		}
+
+		accountNames[account.Name] = 1
+
+		// validate that each account has at least one valid region, its own or the default ones
+		if len(c.WithDefaults(account).Regions) == 0 {
+			v.error(path, accountHasNoRegionsError(account.Name))
+		}
+
+		v.regions(path+".regions", account.Regions, "in account "+account.Name)
+		v.regions(path+".additionalRegions", account.AdditionalRegions, "in account "+account.Name)
+
+		if account.Profile == "" {
+			v.warning(path+".profile", ErrEmptyProfile)
+		}
+
+		v.variables(path+".profile", account.Profile, profileVariables)
+		v.variables(path+".format", account.Format, clusters.FormatVariables)
+		v.extraUsers(path+".extraUsers", account.ExtraUsers, "account "+account.Name)
+
+		for _, key := range slices.Sorted(maps.Keys(account.Labels)) {
+			if err := validateLabel(key, account.Labels[key]); err != nil {
+				v.error(path+".labels."+key, fmt.Errorf("account %s: %w", account.Name, err))
+			}
+		}
+	}
+
+	v.formatCollisions()
+
+	// validate that every group member is an account
+	for _, group := range slices.Sorted(maps.Keys(c.Groups)) {
+		for idx, member := range c.Groups[group] {
+			if _, ok := accountNames[member]; !ok {
+				v.error(fmt.Sprintf("groups.%s[%d]", group, idx), fmt.Errorf("group %s: %w: %s", group,
+					ErrAccountNotFound, member))
+			}
+		}
+	}
+
+	return v.problems
+}
+
+// Problems returns every problem of the config along with the fields of each file that gogok8s doesn't recognise,
+// located within their files and sorted by file and position.
+func (f *File) Problems() []Problem {
+	problems := f.Config.Problems()
+
+	layers := f.Layers
+	if len(layers) == 0 {
+		layers = []*File{f}
+	}
+
+	for _, layer := range layers {
+		for _, field := range layer.UnknownFields {
+			problems = append(problems, Problem{
+				Severity: SeverityWarning,
+				Path:     field,
+				File:     layer.Filename,
+				Err:      ErrUnknownField,
+			})
+		}
+	}
+
+	problems = locateProblems(problems)
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File < problems[j].File
+		}
+
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+
+		return problems[i].Column < problems[j].Column
+	})
+
+	return problems
+}
+
+// locateProblems sets the line and column of every problem whose file can be read, reading each file once.
+func locateProblems(problems []Problem) []Problem {
+	documents := make(map[string]*yaml.Node)
+
+	for idx, problem := range problems {
+		if problem.File == "" {
+			continue
+		}
+
+		document, ok := documents[problem.File]
+		if !ok {
+			document = parseDocument(problem.File)
+			documents[problem.File] = document
+		}
+
+		if node := locate(document, problem.Path, problem.occurrence); node != nil {
+			problems[idx].Line, problems[idx].Column = node.Line, node.Column
+		}
+	}
+
+	return problems
+}
+
+// parseDocument parses a file into yaml nodes, returning nil when it can't be read, e.g. because it's the URL of a
+// source.
+func parseDocument(filename string) *yaml.Node {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil
+	}
+
+	var document yaml.Node
+	if err = yaml.Unmarshal(data, &document); err != nil {
+		return nil
+	}
+
+	return &document
+}
+
+// locate returns the node of the setting at path, e.g. accounts[Prod].regions[1], or of its closest parent that
+// exists. Items of lists are matched by name before index, and the item path ends with is the one after skipping
+// occurrence items of the same name. Keys are matched case-insensitively, like the migration of older files does.
+func locate(document *yaml.Node, path string, occurrence int) *yaml.Node {
+	if document == nil || len(document.Content) == 0 {
+		return nil
+	}
+
+	node := document.Content[0]
+	found := node
+
+	for path != "" {
+		if strings.HasPrefix(path, "[") {
+			end := strings.Index(path, "]")
+			if end < 0 || node.Kind != yaml.SequenceNode {
+				return found
+			}
+
+			skip := 0
+			if end == len(path)-1 {
+				skip = occurrence
+			}
+
+			item := findItem(node, path[1:end], skip)
+			if item == nil {
+				return found
+			}
+
+			node, found, path = item, item, path[end+1:]
+
+			continue
+		}
+
+		path = strings.TrimPrefix(path, ".")
+		if node.Kind != yaml.MappingNode {
+			return found
+		}
+
+		key, value, rest := findKey(node, path)
+		if key == nil {
+			return found
+		}
+
+		node, found, path = value, key, rest
+	}
+
+	return found
+}
+
+func findItem(sequence *yaml.Node, name string, occurrence int) *yaml.Node {
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+
+		for idx := 0; idx+1 < len(item.Content); idx += 2 {
+			if item.Content[idx].Value != "name" || item.Content[idx+1].Value != name {
+				continue
+			}
+
+			if occurrence == 0 {
+				return item
+			}
+
+			occurrence--
+		}
+	}
+
+	if idx, err := strconv.Atoi(name); err == nil && idx >= 0 && idx < len(sequence.Content) {
+		return sequence.Content[idx]
+	}
+
+	return nil
+}
+
+// findKey returns the longest key of the mapping that path starts with, since keys such as labels can contain dots,
+// along with its value and the rest of the path.
+func findKey(mapping *yaml.Node, path string) (*yaml.Node, *yaml.Node, string) {
+	var key, value *yaml.Node
+
+	for idx := 0; idx+1 < len(mapping.Content); idx += 2 {
+		candidate := mapping.Content[idx].Value
+		if len(path) < len(candidate) || !strings.EqualFold(path[:len(candidate)], candidate) {
+			continue
+		}
+
+		if rest := path[len(candidate):]; rest != "" && rest[0] != '.' && rest[0] != '[' {
+			continue
+		}
+
+		if key == nil || len(candidate) > len(key.Value) {
+			key, value = mapping.Content[idx], mapping.Content[idx+1]
+		}
+	}
+
+	if key == nil {
+		return nil, nil, ""
+	}
+
+	return key, value, path[len(key.Value):]
+}
+
+type validator struct {
+	config   *Config
+	problems []Problem
+}
+
+func (v *validator) error(path string, err error) {
+	v.add(SeverityError, path, err)
+}
+
+func (v *validator) warning(path string, err error) {
+	v.add(SeverityWarning, path, err)
+}
+
+// add records a problem, unless the setting is left unexpanded because of an environment variable that isn't set, which
+// is reported instead.
+func (v *validator) add(severity Severity, path string, err error) {
+	if _, ok := v.config.unsetEnv[path]; ok {
+		return
+	}
+
+	v.problems = append(v.problems, v.problem(severity, path, err))
+}
+
+// problem returns a problem along with the file the setting came from when the config was merged from several files.
+func (v *validator) problem(severity Severity, path string, err error) Problem {
+	problem := Problem{Severity: severity, Path: path, Err: err}
+	if v.config.own != nil {
+		problem.File = v.config.SourceFile(path)
+	}
+
+	return problem
+}
+
+func (v *validator) regions(path string, regions []string, where string) {
+	for idx, region := range regions {
+		if !isValidRegion(region) {
+			v.error(fmt.Sprintf("%s[%d]", path, idx), invalidRegionError(fmt.Sprintf("region %s %s", region, where)))
+		}
+	}
+}
+
+// variables flags the ${...} variables of a template that aren't one of known.
+func (v *validator) variables(path, template string, known []string) {
+	for _, variable := range templateVariablePattern.FindAllString(template, -1) {
+		if !slices.Contains(known, variable) {
+			v.error(path, fmt.Errorf("%w %s, expected one of %s", ErrUnknownVariable, variable,
+				strings.Join(known, ", ")))
+		}
+	}
+}
+
+func (v *validator) extraUsers(path string, users []clusters.EKSUser, owner string) {
+	names := make(map[string]int)
+
+	for _, user := range users {
+		userPath := fmt.Sprintf("%s[%s]", path, user.Name)
+
+		if occurrences, ok := names[user.Name]; ok {
+			v.error(userPath, fmt.Errorf("%w: %s in %s", ErrDuplicateExtraUserName, user.Name, owner))
+			v.problems[len(v.problems)-1].occurrence = occurrences
+		}
+
+		names[user.Name]++
+
+		if user.Profile == "" {
+			v.warning(userPath+".profile", ErrEmptyProfile)
+		}
+
+		v.variables(userPath+".profile", user.Profile, profileVariables)
+	}
+}
+
+// FormatCollisions returns the warnings about formats that can generate the same name for different clusters, located
+// within their files, for sync to print before scanning the accounts.
+func (c *Config) FormatCollisions() []Problem {
+	v := &validator{config: c}
+	v.formatCollisions()
+
+	return locateProblems(v.problems)
+}
+
+// formatCollisions warns about formats that can generate the same name for different clusters. Whether they do depends on
+// the clusters found, so sync only handles the names that actually collide, according to onCollision.
+func (v *validator) formatCollisions() {
+	templates := make(map[string]clusters.EKSAccount)
+
+	for _, account := range v.config.ListAccounts() {
+		path := fmt.Sprintf("accounts[%s].format", account.Name)
+
+		format := account.Format
+		if format == "" {
+			format = clusters.DefaultFormat
+		}
+
+		// The ARN is unique to a cluster, so formats using it never collide
+		if strings.Contains(format, "${clusterArn}") {
+			continue
+		}
+
+		if !strings.Contains(format, "${clusterName}") {
+			v.warning(path, collidingFormatError(fmt.Sprintf("%s in account %s contains neither ${clusterName} nor "+
+				"${clusterArn}", format, account.Name)))
+
+			continue
+		}
+
+		hasRegion := strings.Contains(format, "${region}")
+		if !hasRegion && len(account.Regions) > 1 {
+			v.warning(path, collidingFormatError(fmt.Sprintf("%s in account %s scans several regions without "+
+				"containing ${region}", format, account.Name)))
+
+			continue
+		}
+
+		template := strings.ReplaceAll(format, "${name}", account.Name)
+		if other, ok := templates[template]; ok && (!hasRegion || regionsOverlap(account.Regions, other.Regions)) {
+			v.warning(path, collidingFormatError(fmt.Sprintf("accounts %s and %s generate the same names for "+
+				"clusters of the same name", other.Name, account.Name)))
+
+			continue
+		}
+
+		templates[template] = account
+	}
+}
+
+func regionsOverlap(a, b []string) bool {
+	for _, region := range a {
+		if slices.Contains(b, region) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func collidingFormatError(msg string) error {
+	return fmt.Errorf("%w: %s", ErrCollidingFormat, msg)
+}
@@ -0,0 +1,147 @@
+package config_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/config"
+)
+
+func TestFileProblems(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		"team.yaml": `accounts:
+  - name: Team
+    profile: team-${regoin}
+    regions: [us-east-1, us-east-9]
+`,
+		".gogok8s.yaml": `apiVersion: gogok8s/v1
+include: [team.yaml]
+accounts:
+  - name: Dev
+    profile: ""
+    regoins: [us-east-1]
+    regions: [us-east-1]
+    extraUsers:
+      - name: admin
+        profile: admin
+      - name: admin
+        profile: other-admin
+  - name: Dev
+    profile: dev
+    regions: [us-west-2]
+`,
+	})
+	main, team := filepath.Join(dir, ".gogok8s.yaml"), filepath.Join(dir, "team.yaml")
+
+	file, err := config.Load(main)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, problem := range file.Problems() {
+		got = append(got, fmt.Sprintf("%s %s:%d:%d %s", problem.Severity, problem.File, problem.Line, problem.Column,
+			problem.Path))
+	}
+
+	expected := []string{
+		fmt.Sprintf("warning %s:5:5 accounts[Dev].profile", main),
+		fmt.Sprintf("warning %s:6:5 accounts[0].regoins", main),
+		fmt.Sprintf("error %s:11:9 accounts[Dev].extraUsers[admin]", main),
+		fmt.Sprintf("error %s:13:5 accounts[Dev]", main),
+		fmt.Sprintf("error %s:3:5 accounts[Team].profile", team),
+		fmt.Sprintf("error %s:4:26 accounts[Team].regions[1]", team),
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected problems:\n%v\ngot:\n%v", expected, got)
+	}
+
+	// Validate only reports the first error, and ignores warnings
+	if err = file.Config.Validate(); !errors.Is(err, config.ErrInvalidAWSRegion) {
+		t.Errorf("expected ErrInvalidAWSRegion, got %v", err)
+	}
+}
+
+func TestFormatCollisions(t *testing.T) {
+	t.Parallel()
+
+	dir := writeConfigFiles(t, map[string]string{
+		".gogok8s.yaml": `apiVersion: gogok8s/v1
+onCollision: skip
+accounts:
+  - name: Dev
+    profile: dev
+    regions: [us-east-1, us-west-2]
+    format: "${clusterName}"
+  - name: Staging
+    profile: staging
+    regions: [us-east-1]
+    format: "shared.${region}.${clusterName}"
+  - name: Prod
+    profile: prod
+    regions: [us-east-1, eu-west-1]
+    format: "shared.${region}.${clusterName}"
+  - name: Sandbox
+    profile: sandbox
+    regions: [us-east-1]
+`,
+	})
+	filename := filepath.Join(dir, ".gogok8s.yaml")
+
+	file, err := config.Load(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Whether names collide depends on the clusters found, so formats that could collide don't stop a sync
+	if err = file.Config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, problem := range file.Config.FormatCollisions() {
+		if !errors.Is(problem, config.ErrCollidingFormat) {
+			t.Errorf("expected ErrCollidingFormat, got %v", problem)
+		}
+
+		got = append(got, fmt.Sprintf("%s %s:%d %s", problem.Severity, problem.File, problem.Line, problem.Path))
+	}
+
+	expected := []string{
+		fmt.Sprintf("warning %s:7 accounts[Dev].format", filename),
+		fmt.Sprintf("warning %s:15 accounts[Prod].format", filename),
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected collisions:\n%v\ngot:\n%v", expected, got)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	t.Parallel()
+
+	schema := config.Schema()
+
+	properties, _ := schema["properties"].(map[string]any)
+	for _, key := range []string{"apiVersion", "source", "include", "defaults", "accounts", "groups", "onConflict"} {
+		if _, ok := properties[key]; !ok {
+			t.Errorf("expected the schema to describe %s", key)
+		}
+	}
+
+	accounts, _ := properties["accounts"].(map[string]any)
+	account, _ := accounts["items"].(map[string]any)
+	accountProperties, _ := account["properties"].(map[string]any)
+	regions, _ := accountProperties["regions"].(map[string]any)
+	region, _ := regions["items"].(map[string]any)
+
+	if !slices.Equal(region["enum"].([]string), config.ValidRegions) {
+		t.Errorf("expected account regions to be limited to the valid regions, got %v", region)
+	}
+}
@@ -0,0 +1,260 @@
+package discover
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+const (
+	defaultProfile = "default"
+	profilePrefix  = "profile "
+
+	awsCLICommand = "aws"
+)
+
+// The region within the endpoint of an EKS cluster, e.g. https://ABC.gr7.us-east-1.eks.amazonaws.com.
+//
+//nolint:gochecknoglobals
+var endpointRegionPattern = regexp.MustCompile(`\.([a-z]{2}(?:-[a-z]+)+-\d+)\.eks\.amazonaws\.com`)
+
+// Profile is a profile of the shared AWS config file.
+type Profile struct {
+	Name string
+	// The region set by the profile, if any.
+	Region string
+}
+
+// Proposal is an account proposed for a profile.
+type Proposal struct {
+	Account clusters.EKSAccount
+	// Whether the regions come from kubeconfig users of the profile, rather than only from the AWS config.
+	InKubeConfig bool
+}
+
+// LoadAWSProfiles reads the profiles of the shared AWS config file, $AWS_CONFIG_FILE or ~/.aws/config, returning none
+// when it doesn't exist.
+func LoadAWSProfiles() ([]Profile, error) {
+	filename := os.Getenv("AWS_CONFIG_FILE")
+	if filename == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user home directory: %w", err)
+		}
+
+		filename = filepath.Join(home, ".aws", "config")
+	}
+
+	file, err := os.Open(filename)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read AWS config: %w", err)
+	}
+	defer file.Close()
+
+	return ParseAWSConfig(file)
+}
+
+// ParseAWSConfig parses the profiles of a shared AWS config file. Sections other than [default] and [profile name],
+// such as [sso-session name], are skipped.
+func ParseAWSConfig(r io.Reader) ([]Profile, error) {
+	var profiles []Profile
+
+	// The index of the profile whose section is being read, or -1 within other sections
+	current := -1
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.TrimSpace(line[1 : len(line)-1])
+			current = -1
+
+			if name, ok := strings.CutPrefix(section, profilePrefix); ok {
+				profiles = append(profiles, Profile{Name: strings.TrimSpace(name)})
+				current = len(profiles) - 1
+			} else if section == defaultProfile {
+				profiles = append(profiles, Profile{Name: defaultProfile})
+				current = len(profiles) - 1
+			}
+
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if ok && current >= 0 && strings.TrimSpace(key) == "region" {
+			profiles[current].Region = strings.TrimSpace(value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS config: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// KubeConfigRegions returns the regions of the EKS clusters each profile is used for by the kubeconfig, found from
+// the users that authenticate with aws-iam-authenticator or `aws eks get-token`. Entries created by gogok8s are
+// skipped, since their accounts are already configured.
+func KubeConfigRegions(kubeconfig *api.Config) map[string][]string {
+	regions := make(map[string][]string)
+
+	for name, user := range kubeconfig.AuthInfos {
+		if user.Exec == nil {
+			continue
+		}
+
+		if _, managed := kubecfg.GetMetadata(user.Extensions); managed {
+			continue
+		}
+
+		profile, region, ok := parseExecConfig(user.Exec)
+		if !ok || profile == "" {
+			continue
+		}
+
+		if region == "" {
+			region = userEndpointRegion(kubeconfig, name)
+		}
+
+		if region != "" && !slices.Contains(regions[profile], region) {
+			regions[profile] = append(regions[profile], region)
+		}
+	}
+
+	for _, profileRegions := range regions {
+		sort.Strings(profileRegions)
+	}
+
+	return regions
+}
+
+// parseExecConfig returns the profile and region an exec config authenticates to EKS with, or false when it doesn't
+// authenticate to EKS. Either can be empty when the command doesn't set them.
+func parseExecConfig(exec *api.ExecConfig) (string, string, bool) {
+	command := filepath.Base(exec.Command)
+
+	var cluster string
+
+	switch {
+	case command == clusters.AuthenticatorCommand && slices.Contains(exec.Args, "token"):
+		cluster = argValue(exec.Args, "-i", "--cluster-id")
+	case command == awsCLICommand && slices.Contains(exec.Args, "eks") && slices.Contains(exec.Args, "get-token"):
+		cluster = argValue(exec.Args, "--cluster-name")
+	default:
+		return "", "", false
+	}
+
+	profile := argValue(exec.Args, "--profile")
+	if profile == "" {
+		for _, env := range exec.Env {
+			if env.Name == "AWS_PROFILE" {
+				profile = env.Value
+			}
+		}
+	}
+
+	region := argValue(exec.Args, "--region")
+	if region == "" && strings.HasPrefix(cluster, "arn:") {
+		// arn:aws:eks:<region>:<account>:cluster/<name>
+		if parts := strings.Split(cluster, ":"); len(parts) > 3 {
+			region = parts[3]
+		}
+	}
+
+	return profile, region, true
+}
+
+// argValue returns the value of the first of the flags within args, passed as either `--flag value` or
+// `--flag=value`.
+func argValue(args []string, flags ...string) string {
+	for idx, arg := range args {
+		for _, flag := range flags {
+			if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+				return value
+			}
+
+			if arg == flag && idx+1 < len(args) {
+				return args[idx+1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// userEndpointRegion returns the region within the endpoint of a cluster the user is used with by a context.
+func userEndpointRegion(kubeconfig *api.Config, user string) string {
+	for _, name := range slices.Sorted(maps.Keys(kubeconfig.Contexts)) {
+		context := kubeconfig.Contexts[name]
+		if context.AuthInfo != user {
+			continue
+		}
+
+		if cluster, ok := kubeconfig.Clusters[context.Cluster]; ok {
+			if match := endpointRegionPattern.FindStringSubmatch(cluster.Server); match != nil {
+				return match[1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// Propose returns an account for every profile, named after it. Accounts of profiles used by the kubeconfig scan the
+// regions they're used in, while the rest scan the region of the profile, and profiles without either are left out.
+// Accounts found in the kubeconfig come first.
+func Propose(profiles []Profile, kubeconfigRegions map[string][]string) []Proposal {
+	var proposals []Proposal
+
+	for _, profile := range slices.Sorted(maps.Keys(kubeconfigRegions)) {
+		proposals = append(proposals, Proposal{
+			Account: clusters.EKSAccount{
+				Name:    profile,
+				Profile: profile,
+				Regions: kubeconfigRegions[profile],
+			},
+			InKubeConfig: true,
+		})
+	}
+
+	profiles = slices.Clone(profiles)
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	for _, profile := range profiles {
+		if _, ok := kubeconfigRegions[profile.Name]; ok || profile.Region == "" {
+			continue
+		}
+
+		proposals = append(proposals, Proposal{
+			Account: clusters.EKSAccount{
+				Name:    profile.Name,
+				Profile: profile.Name,
+				Regions: []string{profile.Region},
+			},
+		})
+	}
+
+	return proposals
+}
@@ -0,0 +1,111 @@
+package discover_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/discover"
+)
+
+func TestParseAWSConfig(t *testing.T) {
+	t.Parallel()
+
+	profiles, err := discover.ParseAWSConfig(strings.NewReader(`
+[default]
+region = us-east-1
+
+# The SSO session isn't a profile
+[sso-session corp]
+region = eu-west-1
+
+[profile dev]
+sso_session = corp
+region=us-west-2
+
+[profile prod]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []discover.Profile{
+		{Name: "default", Region: "us-east-1"},
+		{Name: "dev", Region: "us-west-2"},
+		{Name: "prod"},
+	}
+	if !reflect.DeepEqual(profiles, expected) {
+		t.Errorf("expected %+v, got %+v", expected, profiles)
+	}
+}
+
+func TestKubeConfigRegions(t *testing.T) {
+	t.Parallel()
+
+	kubeconfig := api.NewConfig()
+	kubeconfig.AuthInfos["arn"] = &api.AuthInfo{Exec: &api.ExecConfig{
+		Command: "aws",
+		Args: []string{
+			"--region", "us-west-2", "eks", "get-token",
+			"--cluster-name", "arn:aws:eks:us-west-2:111111111111:cluster/a",
+		},
+		Env: []api.ExecEnvVar{{Name: "AWS_PROFILE", Value: "dev"}},
+	}}
+	kubeconfig.AuthInfos["authenticator"] = &api.AuthInfo{Exec: &api.ExecConfig{
+		Command: "/usr/local/bin/aws-iam-authenticator",
+		Args:    []string{"token", "-i", "b"},
+		Env:     []api.ExecEnvVar{{Name: "AWS_PROFILE", Value: "dev"}},
+	}}
+	kubeconfig.AuthInfos["flag"] = &api.AuthInfo{Exec: &api.ExecConfig{
+		Command: "aws",
+		Args:    []string{"eks", "get-token", "--cluster-name=c", "--profile=prod", "--region=eu-west-1"},
+	}}
+	kubeconfig.AuthInfos["other"] = &api.AuthInfo{Exec: &api.ExecConfig{
+		Command: "gke-gcloud-auth-plugin",
+		Env:     []api.ExecEnvVar{{Name: "AWS_PROFILE", Value: "gke"}},
+	}}
+
+	// The region of the authenticator user is only known from the endpoint of its cluster
+	kubeconfig.Clusters["b"] = &api.Cluster{Server: "https://ABCDEF.gr7.ap-south-1.eks.amazonaws.com"}
+	kubeconfig.Contexts["b"] = &api.Context{Cluster: "b", AuthInfo: "authenticator"}
+
+	expected := map[string][]string{
+		"dev":  {"ap-south-1", "us-west-2"},
+		"prod": {"eu-west-1"},
+	}
+	if regions := discover.KubeConfigRegions(kubeconfig); !reflect.DeepEqual(regions, expected) {
+		t.Errorf("expected %v, got %v", expected, regions)
+	}
+}
+
+func TestPropose(t *testing.T) {
+	t.Parallel()
+
+	profiles := []discover.Profile{
+		{Name: "sandbox", Region: "us-east-2"},
+		{Name: "dev", Region: "us-east-1"},
+		{Name: "no-region"},
+	}
+
+	proposals := discover.Propose(profiles, map[string][]string{"dev": {"us-west-2"}})
+
+	var names []string
+	for _, proposal := range proposals {
+		names = append(names, proposal.Account.Name)
+	}
+
+	if !reflect.DeepEqual(names, []string{"dev", "sandbox"}) {
+		t.Fatalf("expected accounts dev and sandbox, got %v", names)
+	}
+
+	dev := proposals[0]
+	if !dev.InKubeConfig || !reflect.DeepEqual(dev.Account.Regions, []string{"us-west-2"}) {
+		t.Errorf("expected dev to scan the regions of the kubeconfig, got %+v", dev)
+	}
+
+	if profiles[0].Name != "sandbox" {
+		t.Error("expected the profiles passed in to be left unsorted")
+	}
+}
@@ -0,0 +1,320 @@
+package doctor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+
+	defaultTimeout = 15 * time.Second
+)
+
+// Result is the outcome of a single check, with a hint on how to fix it when the check didn't pass.
+type Result struct {
+	Check   string
+	Status  Status
+	Message string
+	Hint    string
+}
+
+type STSAPI interface {
+	GetCallerIdentity(
+		ctx context.Context,
+		params *sts.GetCallerIdentityInput,
+		optFns ...func(*sts.Options),
+	) (*sts.GetCallerIdentityOutput, error)
+}
+
+// Doctor checks the environment gogok8s runs in. The functions that reach outside the process can be replaced for
+// testing.
+type Doctor struct {
+	Accounts       []clusters.EKSAccount
+	ConfigFile     string
+	KubeConfigFile string
+
+	// LoadProfile returns an error when the profile doesn't exist within the shared AWS config.
+	LoadProfile func(profile string) error
+	// NewSTSClient returns an STS client that uses the credentials of the profile.
+	NewSTSClient func(profile string) (STSAPI, error)
+	// LookPath finds an executable within PATH.
+	LookPath func(file string) (string, error)
+}
+
+func New(accounts []clusters.EKSAccount, configFile, kubeconfigFile string) *Doctor {
+	return &Doctor{
+		Accounts:       accounts,
+		ConfigFile:     configFile,
+		KubeConfigFile: kubeconfigFile,
+		LoadProfile:    loadSharedConfigProfile,
+		NewSTSClient:   newSTSClient,
+		LookPath:       exec.LookPath,
+	}
+}
+
+// Run runs every check, returning the results in a stable order.
+func (d *Doctor) Run() []Result {
+	var results []Result
+
+	results = append(results, d.checkConfigFile())
+	results = append(results, d.checkProfiles()...)
+	results = append(results, d.checkAuthenticator())
+	results = append(results, d.checkKubeConfig()...)
+
+	return results
+}
+
+func (d *Doctor) checkConfigFile() Result {
+	result := Result{Check: "config file"}
+
+	info, err := os.Stat(d.ConfigFile)
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = err.Error()
+		result.Hint = "run `gogok8s configure` to create a config file"
+
+		return result
+	}
+
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o022 != 0 {
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("%s is writable by other users (mode %o)", d.ConfigFile, info.Mode().Perm())
+		result.Hint = "chmod 644 " + d.ConfigFile
+
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Message = fmt.Sprintf("%s (mode %o)", d.ConfigFile, info.Mode().Perm())
+
+	return result
+}
+
+// checkProfiles checks that every profile used by an account exists and that its credentials resolve. Profiles are
+// checked concurrently since each credential check calls STS.
+func (d *Doctor) checkProfiles() []Result {
+	profiles := d.listProfiles()
+	results := make([][]Result, len(profiles))
+
+	var wg sync.WaitGroup
+
+	for idx, profile := range profiles {
+		wg.Add(1)
+
+		go func(idx int, profile string) {
+			defer wg.Done()
+
+			results[idx] = d.checkProfile(profile)
+		}(idx, profile)
+	}
+
+	wg.Wait()
+
+	var flattened []Result
+	for _, profileResults := range results {
+		flattened = append(flattened, profileResults...)
+	}
+
+	return flattened
+}
+
+func (d *Doctor) checkProfile(profile string) []Result {
+	existsResult := Result{Check: fmt.Sprintf("profile %s", profile)}
+
+	if err := d.LoadProfile(profile); err != nil {
+		existsResult.Status = StatusFail
+		existsResult.Message = err.Error()
+		existsResult.Hint = fmt.Sprintf("add a [profile %s] section to ~/.aws/config or fix the profile name in the config", profile)
+
+		return []Result{existsResult}
+	}
+
+	existsResult.Status = StatusPass
+	existsResult.Message = "found in shared AWS config"
+
+	credentialsResult := Result{Check: fmt.Sprintf("credentials %s", profile)}
+
+	client, err := d.NewSTSClient(profile)
+	if err != nil {
+		credentialsResult.Status = StatusFail
+		credentialsResult.Message = err.Error()
+		credentialsResult.Hint = "check the credential settings of the profile"
+
+		return []Result{existsResult, credentialsResult}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	identity, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		credentialsResult.Status = StatusFail
+		credentialsResult.Message = err.Error()
+		credentialsResult.Hint = fmt.Sprintf("refresh your credentials, e.g. `aws sso login --profile %s`", profile)
+
+		return []Result{existsResult, credentialsResult}
+	}
+
+	credentialsResult.Status = StatusPass
+	credentialsResult.Message = aws.ToString(identity.Arn)
+
+	return []Result{existsResult, credentialsResult}
+}
+
+func (d *Doctor) checkAuthenticator() Result {
+	result := Result{Check: "authenticator"}
+
+	path, err := d.LookPath(clusters.AuthenticatorCommand)
+	if err != nil {
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("%s was not found in PATH", clusters.AuthenticatorCommand)
+		result.Hint = "install it from https://github.com/kubernetes-sigs/aws-iam-authenticator/releases"
+
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Message = path
+
+	return result
+}
+
+func (d *Doctor) checkKubeConfig() []Result {
+	result := Result{Check: "kubeconfig"}
+
+	info, err := os.Stat(d.KubeConfigFile)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		result.Status = StatusWarn
+		result.Message = d.KubeConfigFile + " does not exist"
+		result.Hint = "run `gogok8s sync` to create it"
+
+		return []Result{result}
+	} else if err != nil {
+		result.Status = StatusFail
+		result.Message = err.Error()
+		result.Hint = "check the permissions of the kubeconfig and its directory"
+
+		return []Result{result}
+	}
+
+	switch {
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0:
+		result.Status = StatusWarn
+		result.Message = fmt.Sprintf("%s is accessible by other users (mode %o)", d.KubeConfigFile, info.Mode().Perm())
+		result.Hint = "chmod 600 " + d.KubeConfigFile
+	case runtime.GOOS != "windows" && info.Mode().Perm()&0o200 == 0:
+		result.Status = StatusFail
+		result.Message = fmt.Sprintf("%s is not writable (mode %o)", d.KubeConfigFile, info.Mode().Perm())
+		result.Hint = "chmod 600 " + d.KubeConfigFile
+	default:
+		result.Status = StatusPass
+		result.Message = fmt.Sprintf("%s (mode %o)", d.KubeConfigFile, info.Mode().Perm())
+	}
+
+	kubeconfig, err := kubecfg.LoadFromFile(d.KubeConfigFile)
+	if err != nil {
+		return []Result{result, {
+			Check:   "kubeconfig contexts",
+			Status:  StatusFail,
+			Message: err.Error(),
+			Hint:    "fix the kubeconfig syntax or restore a backup with `gogok8s restore`",
+		}}
+	}
+
+	return []Result{result, checkContextReferences(kubeconfig)}
+}
+
+func checkContextReferences(kubeconfig *api.Config) Result {
+	result := Result{Check: "kubeconfig contexts"}
+
+	var problems []string
+
+	for name, context := range kubeconfig.Contexts {
+		if _, ok := kubeconfig.Clusters[context.Cluster]; !ok {
+			problems = append(problems, fmt.Sprintf("%s references missing cluster %s", name, context.Cluster))
+		}
+
+		if _, ok := kubeconfig.AuthInfos[context.AuthInfo]; !ok {
+			problems = append(problems, fmt.Sprintf("%s references missing user %s", name, context.AuthInfo))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+
+		result.Status = StatusFail
+		result.Message = strings.Join(problems, "; ")
+		result.Hint = "run `gogok8s sync` to recreate gogok8s entries, or remove the broken contexts with `kubectl config delete-context`"
+
+		return result
+	}
+
+	result.Status = StatusPass
+	result.Message = fmt.Sprintf("%d contexts reference existing clusters and users", len(kubeconfig.Contexts))
+
+	return result
+}
+
+// listProfiles returns every unique profile used by the accounts in each of their regions, including the profiles of
+// extra users.
+func (d *Doctor) listProfiles() []string {
+	seen := make(map[string]struct{})
+
+	var profiles []string
+
+	add := func(profile string) {
+		if _, ok := seen[profile]; ok {
+			return
+		}
+
+		seen[profile] = struct{}{}
+		profiles = append(profiles, profile)
+	}
+
+	for _, account := range d.Accounts {
+		for _, profile := range account.ListProfiles() {
+			add(profile)
+		}
+	}
+
+	return profiles
+}
+
+func loadSharedConfigProfile(profile string) error {
+	_, err := config.LoadSharedConfigProfile(context.Background(), profile)
+	if err != nil {
+		return fmt.Errorf("failed to load profile: %w", err)
+	}
+
+	return nil
+}
+
+func newSTSClient(profile string) (STSAPI, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithSharedConfigProfile(profile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return sts.NewFromConfig(cfg), nil
+}
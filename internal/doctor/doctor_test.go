@@ -0,0 +1,128 @@
+package doctor_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/BigPapaChas/gogok8s/internal/clusters"
+	"github.com/BigPapaChas/gogok8s/internal/doctor"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: foo
+  cluster:
+    server: https://localhost:8080
+users:
+- name: foo
+  user:
+    token: bar
+contexts:
+- name: foo
+  context:
+    cluster: foo
+    user: foo
+- name: dangling
+  context:
+    cluster: missing
+    user: foo
+`
+
+var errExpired = errors.New("token expired")
+
+type STSMock struct {
+	Err error
+}
+
+func (m STSMock) GetCallerIdentity(
+	ctx context.Context,
+	params *sts.GetCallerIdentityInput,
+	optFns ...func(*sts.Options),
+) (*sts.GetCallerIdentityOutput, error) {
+	if m.Err != nil {
+		return nil, m.Err
+	}
+
+	return &sts.GetCallerIdentityOutput{Arn: aws.String("arn:aws:iam::123456789012:user/test")}, nil
+}
+
+func TestDoctorRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, ".gogok8s.yaml")
+	kubeconfigFile := filepath.Join(dir, "config")
+
+	if err := os.WriteFile(configFile, []byte("accounts: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(kubeconfigFile, []byte(testKubeConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := []clusters.EKSAccount{
+		{Name: "dev", Profile: "dev", ExtraUsers: []clusters.EKSUser{{Name: "admin", Profile: "expired"}}},
+		{Name: "prod", Profile: "missing"},
+	}
+
+	d := doctor.New(accounts, configFile, kubeconfigFile)
+	d.LoadProfile = func(profile string) error {
+		if profile == "missing" {
+			return errors.New("profile not found")
+		}
+
+		return nil
+	}
+	d.NewSTSClient = func(profile string) (doctor.STSAPI, error) {
+		if profile == "expired" {
+			return STSMock{Err: errExpired}, nil
+		}
+
+		return STSMock{}, nil
+	}
+	d.LookPath = func(file string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	expected := map[string]doctor.Status{
+		"config file":         doctor.StatusPass,
+		"profile dev":         doctor.StatusPass,
+		"credentials dev":     doctor.StatusPass,
+		"profile expired":     doctor.StatusPass,
+		"credentials expired": doctor.StatusFail,
+		"profile missing":     doctor.StatusFail,
+		"authenticator":       doctor.StatusFail,
+		"kubeconfig":          doctor.StatusPass,
+		"kubeconfig contexts": doctor.StatusFail,
+	}
+
+	results := d.Run()
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d: %+v", len(expected), len(results), results)
+	}
+
+	for _, result := range results {
+		status, ok := expected[result.Check]
+		if !ok {
+			t.Errorf("unexpected check %s", result.Check)
+
+			continue
+		}
+
+		if result.Status != status {
+			t.Errorf("expected %s to be %s, got %s: %s", result.Check, status, result.Status, result.Message)
+		}
+
+		if result.Status != doctor.StatusPass && result.Hint == "" {
+			t.Errorf("expected a hint for %s", result.Check)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package fanout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Job is a command to run, identified by a name that prefixes every line of its output.
+type Job struct {
+	Name    string
+	Command []string
+	// Env is the full environment of the command, the environment of gogok8s is used when nil.
+	Env []string
+}
+
+type Result struct {
+	Name     string
+	ExitCode int
+	Err      error
+	Duration time.Duration
+}
+
+func (r Result) Succeeded() bool {
+	return r.Err == nil
+}
+
+// Run runs the jobs with at most parallel running at once, writing their output line by line to stdout and stderr with
+// each line prefixed by the job name. Results are returned in the same order as the jobs.
+func Run(ctx context.Context, jobs []Job, parallel int, stdout, stderr io.Writer) []Result {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, parallel)
+	)
+
+	results := make([]Result, len(jobs))
+
+	for idx, job := range jobs {
+		wg.Add(1)
+
+		go func(idx int, job Job) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			prefix := "[" + job.Name + "] "
+			out := &prefixWriter{prefix: prefix, w: stdout, mu: &mu}
+			errOut := &prefixWriter{prefix: prefix, w: stderr, mu: &mu}
+
+			results[idx] = runJob(ctx, job, out, errOut)
+
+			out.Flush()
+			errOut.Flush()
+		}(idx, job)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func runJob(ctx context.Context, job Job, stdout, stderr io.Writer) Result {
+	result := Result{Name: job.Name}
+	start := time.Now()
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, job.Command[0], job.Command[1:]...)
+	cmd.Env = job.Env
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	result.Err = cmd.Run()
+	result.Duration = time.Since(start)
+
+	var exitErr *exec.ExitError
+	if errors.As(result.Err, &exitErr) {
+		result.ExitCode = exitErr.ExitCode()
+	} else if result.Err != nil {
+		result.ExitCode = -1
+	}
+
+	return result
+}
+
+// prefixWriter writes complete lines with a prefix, so the output of concurrent jobs never interleaves mid-line. The
+// mutex is shared between every writer of a run.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+	mu     *sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buf.Write(data)
+
+	for {
+		idx := bytes.IndexByte(p.buf.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+
+		p.writeLine(p.buf.Next(idx + 1))
+	}
+
+	return len(data), nil
+}
+
+// Flush writes any trailing output that didn't end with a newline.
+func (p *prefixWriter) Flush() {
+	if p.buf.Len() > 0 {
+		p.writeLine(append(p.buf.Bytes(), '\n'))
+		p.buf.Reset()
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, _ = io.WriteString(p.w, p.prefix)
+	_, _ = p.w.Write(line)
+}
@@ -0,0 +1,40 @@
+package fanout_test
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/fanout"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	jobs := []fanout.Job{
+		{Name: "ok", Command: []string{"sh", "-c", "echo one; printf two"}},
+		{Name: "fail", Command: []string{"sh", "-c", "echo oops >&2; exit 3"}},
+		{Name: "missing", Command: []string{"gogok8s-command-that-does-not-exist"}},
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	results := fanout.Run(context.Background(), jobs, 2, &stdout, &stderr)
+
+	if !results[0].Succeeded() || results[1].Succeeded() || results[1].ExitCode != 3 || results[2].Succeeded() {
+		t.Errorf("unexpected results %+v", results)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	sort.Strings(lines)
+
+	if strings.Join(lines, "|") != "[ok] one|[ok] two" {
+		t.Errorf("unexpected stdout %q", stdout.String())
+	}
+
+	if stderr.String() != "[fail] oops\n" {
+		t.Errorf("unexpected stderr %q", stderr.String())
+	}
+}
@@ -0,0 +1,166 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var ErrEntryExists = errors.New("kubeconfig entry already exists")
+
+// RemoveAccount removes every cluster, user and context gogok8s generated for the account, returning a diff of the
+// removed entries.
+func RemoveAccount(config *api.Config, account string) *Diff {
+	diff := &Diff{
+		Clusters: removeAccountEntries(config.Clusters, account, func(c *api.Cluster) map[string]runtime.Object {
+			return c.Extensions
+		}),
+		Users: removeAccountEntries(config.AuthInfos, account, func(u *api.AuthInfo) map[string]runtime.Object {
+			return u.Extensions
+		}),
+		Contexts: removeAccountEntries(config.Contexts, account, func(c *api.Context) map[string]runtime.Object {
+			return c.Extensions
+		}),
+	}
+
+	if _, ok := config.Contexts[config.CurrentContext]; !ok && config.CurrentContext != "" {
+		diff.CurrentContext = &FieldChange{Field: "current-context", Old: config.CurrentContext}
+		config.CurrentContext = ""
+	}
+
+	diff.sort()
+
+	return diff
+}
+
+func removeAccountEntries[T any](entries map[string]*T, account string, extensions func(*T) map[string]runtime.Object) []Change {
+	var changes []Change
+
+	for name, entry := range entries {
+		metadata, ok := GetMetadata(extensions(entry))
+		if !ok || metadata.Account != account {
+			continue
+		}
+
+		changes = append(changes, Change{Name: name, Type: ChangeRemoved})
+		delete(entries, name)
+	}
+
+	return changes
+}
+
+// RenameAccount moves every entry gogok8s generated for an account over to a new account name. The new name of each
+// entry is returned by entryName, which is passed the entry's metadata with the account already renamed. Context
+// references and the current-context follow the renamed entries.
+func RenameAccount(config *api.Config, oldAccount, newAccount string, entryName func(Metadata) string) (*Diff, error) {
+	clusterNames, err := renameAccountEntries(config.Clusters, oldAccount, newAccount, entryName,
+		func(c *api.Cluster) *map[string]runtime.Object { return &c.Extensions })
+	if err != nil {
+		return nil, fmt.Errorf("cluster %w", err)
+	}
+
+	userNames, err := renameAccountEntries(config.AuthInfos, oldAccount, newAccount, entryName,
+		func(u *api.AuthInfo) *map[string]runtime.Object { return &u.Extensions })
+	if err != nil {
+		return nil, fmt.Errorf("user %w", err)
+	}
+
+	contextNames, err := renameAccountEntries(config.Contexts, oldAccount, newAccount, entryName,
+		func(c *api.Context) *map[string]runtime.Object { return &c.Extensions })
+	if err != nil {
+		return nil, fmt.Errorf("context %w", err)
+	}
+
+	diff := &Diff{
+		Clusters: renameChanges(clusterNames),
+		Users:    renameChanges(userNames),
+		Contexts: renameChanges(contextNames),
+	}
+
+	for _, context := range config.Contexts {
+		if name, ok := clusterNames[context.Cluster]; ok {
+			context.Cluster = name
+		}
+
+		if name, ok := userNames[context.AuthInfo]; ok {
+			context.AuthInfo = name
+		}
+	}
+
+	if name, ok := contextNames[config.CurrentContext]; ok && name != config.CurrentContext {
+		diff.CurrentContext = &FieldChange{Field: "current-context", Old: config.CurrentContext, New: name}
+		config.CurrentContext = name
+	}
+
+	diff.sort()
+
+	return diff, nil
+}
+
+// renameAccountEntries renames the entries of an account in place, returning a map of old names to new names. Nothing
+// is modified when a new name collides with an entry that isn't being renamed.
+func renameAccountEntries[T any](
+	entries map[string]*T,
+	oldAccount, newAccount string,
+	entryName func(Metadata) string,
+	extensions func(*T) *map[string]runtime.Object,
+) (map[string]string, error) {
+	renames := make(map[string]string)
+	metadatas := make(map[string]Metadata)
+
+	for name, entry := range entries {
+		metadata, ok := GetMetadata(*extensions(entry))
+		if !ok || metadata.Account != oldAccount {
+			continue
+		}
+
+		metadata.Account = newAccount
+		renames[name] = entryName(metadata)
+		metadatas[name] = metadata
+	}
+
+	for _, newName := range renames {
+		if _, renamed := renames[newName]; renamed {
+			continue
+		}
+
+		if _, ok := entries[newName]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrEntryExists, newName)
+		}
+	}
+
+	moved := make(map[string]*T, len(renames))
+
+	for oldName := range renames {
+		entry := entries[oldName]
+		*extensions(entry) = mergeMetadataExtensions(*extensions(entry), NewMetadataExtensions(metadatas[oldName]))
+		moved[oldName] = entry
+
+		delete(entries, oldName)
+	}
+
+	for oldName, entry := range moved {
+		entries[renames[oldName]] = entry
+	}
+
+	return renames, nil
+}
+
+func renameChanges(renames map[string]string) []Change {
+	var changes []Change
+
+	for oldName, newName := range renames {
+		if oldName == newName {
+			continue
+		}
+
+		changes = append(changes,
+			Change{Name: oldName, Type: ChangeRemoved},
+			Change{Name: newName, Type: ChangeAdded},
+		)
+	}
+
+	return changes
+}
@@ -0,0 +1,70 @@
+package kubecfg_test
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func TestRenameAccount(t *testing.T) {
+	t.Parallel()
+
+	config := api.NewConfig()
+	kubecfg.ApplyPatch(newTestPatch("Dev.foo", "https://localhost:7777", "dev"), config, kubecfg.ApplyOptions{})
+	config.CurrentContext = "Dev.foo"
+
+	entryName := func(metadata kubecfg.Metadata) string {
+		return metadata.Account + "." + metadata.ClusterName
+	}
+
+	// A collision with an entry that isn't being renamed leaves the kubeconfig untouched
+	config.Clusters["Prod.Dev.foo"] = &api.Cluster{Server: "https://localhost:9999"}
+
+	if _, err := kubecfg.RenameAccount(config, "Dev", "Prod", entryName); !errors.Is(err, kubecfg.ErrEntryExists) {
+		t.Fatalf("expected ErrEntryExists, got %v", err)
+	}
+
+	if _, ok := config.Clusters["Dev.foo"]; !ok {
+		t.Fatal("expected cluster Dev.foo to be kept after a failed rename")
+	}
+
+	delete(config.Clusters, "Prod.Dev.foo")
+
+	diff, err := kubecfg.RenameAccount(config, "Dev", "Prod", entryName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if change := findChange(t, diff.Contexts, "Prod.Dev.foo"); change.Type != kubecfg.ChangeAdded {
+		t.Errorf("expected context Prod.Dev.foo to be added, but it was %s", change.Type)
+	}
+
+	context, ok := config.Contexts["Prod.Dev.foo"]
+	if !ok {
+		t.Fatal("expected context Prod.Dev.foo to exist")
+	}
+
+	if context.Cluster != "Prod.Dev.foo" || context.AuthInfo != "Prod.Dev.foo" {
+		t.Errorf("expected context references to be renamed, got %+v", context)
+	}
+
+	if metadata, _ := kubecfg.GetMetadata(context.Extensions); metadata.Account != "Prod" {
+		t.Errorf("expected metadata account Prod, got %s", metadata.Account)
+	}
+
+	if config.CurrentContext != "Prod.Dev.foo" {
+		t.Errorf("expected current-context to follow the rename, got %s", config.CurrentContext)
+	}
+
+	diff = kubecfg.RemoveAccount(config, "Prod")
+	if len(diff.Clusters) != 1 || len(diff.Users) != 1 || len(diff.Contexts) != 1 || diff.CurrentContext == nil {
+		t.Errorf("expected every entry of Prod to be removed, got %+v", diff)
+	}
+
+	if len(config.Clusters)+len(config.AuthInfos)+len(config.Contexts) != 0 || config.CurrentContext != "" {
+		t.Errorf("expected an empty kubeconfig, got %+v", config)
+	}
+}
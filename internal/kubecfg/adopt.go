@@ -0,0 +1,236 @@
+package kubecfg
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd/api"
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+// adoption maps the names of the entries being adopted to the patch entries they are renamed to.
+type adoption struct {
+	clusters map[string]*v1.NamedCluster
+	users    map[string]*v1.NamedAuthInfo
+	contexts map[string]*v1.NamedContext
+}
+
+// Adopt renames the clusters, users and contexts gogok8s did not create but which point at a cluster of the patch,
+// such as the ones written by `aws eks update-kubeconfig`, to the names gogok8s generates. A cluster is matched by its
+// server or by being named after the cluster's ARN. Adopted entries are marked as managed, so applying the patch
+// afterwards updates them rather than adding duplicates, and an entry whose new name gogok8s already manages is removed
+// in favour of it. The namespace of adopted contexts and the current-context are kept, and contexts follow the renamed
+// entries.
+func Adopt(patch *KubeConfigPatch, config *api.Config) *Diff {
+	diff := &Diff{}
+
+	if patch == nil {
+		return diff
+	}
+
+	adopted := findAdoptions(patch, config)
+
+	clusterNames := make(map[string]string)
+	for _, oldName := range slices.Sorted(maps.Keys(adopted.clusters)) {
+		cluster := adopted.clusters[oldName]
+		clusterNames[oldName] = cluster.Name
+		diff.Clusters = append(diff.Clusters, adoptEntry(config.Clusters, oldName, cluster.Name,
+			cluster.Cluster.Extensions, func(c *api.Cluster) *map[string]runtime.Object { return &c.Extensions })...)
+	}
+
+	userNames := make(map[string]string)
+	for _, oldName := range slices.Sorted(maps.Keys(adopted.users)) {
+		user := adopted.users[oldName]
+		userNames[oldName] = user.Name
+		diff.Users = append(diff.Users, adoptEntry(config.AuthInfos, oldName, user.Name,
+			user.AuthInfo.Extensions, func(u *api.AuthInfo) *map[string]runtime.Object { return &u.Extensions })...)
+	}
+
+	contextNames := make(map[string]string)
+	for _, oldName := range slices.Sorted(maps.Keys(adopted.contexts)) {
+		context := adopted.contexts[oldName]
+		contextNames[oldName] = context.Name
+
+		// The namespace is kept when the context is merged into the one gogok8s already manages
+		if existing, ok := config.Contexts[context.Name]; ok && existing.Namespace == "" {
+			before := contextFields(existing)
+			existing.Namespace = config.Contexts[oldName].Namespace
+
+			if change := diffEntry(context.Name, before, contextFields(existing)); change != nil {
+				diff.Contexts = append(diff.Contexts, *change)
+			}
+		}
+
+		diff.Contexts = append(diff.Contexts, adoptEntry(config.Contexts, oldName, context.Name,
+			context.Context.Extensions, func(c *api.Context) *map[string]runtime.Object { return &c.Extensions })...)
+	}
+
+	for _, context := range config.Contexts {
+		if name, ok := clusterNames[context.Cluster]; ok {
+			context.Cluster = name
+		}
+
+		if name, ok := userNames[context.AuthInfo]; ok {
+			context.AuthInfo = name
+		}
+	}
+
+	if name, ok := contextNames[config.CurrentContext]; ok {
+		diff.CurrentContext = &FieldChange{Field: "current-context", Old: config.CurrentContext, New: name}
+		config.CurrentContext = name
+	}
+
+	diff.sort()
+
+	return diff
+}
+
+// findAdoptions finds the unmanaged entries of the kubeconfig that point at a cluster of the patch. Entries whose new
+// name is taken by another entry gogok8s didn't create are left alone, as are users shared with contexts that aren't
+// being adopted.
+func findAdoptions(patch *KubeConfigPatch, config *api.Config) adoption {
+	adopted := adoption{
+		clusters: make(map[string]*v1.NamedCluster),
+		users:    make(map[string]*v1.NamedAuthInfo),
+		contexts: make(map[string]*v1.NamedContext),
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(config.Clusters)) {
+		existing := config.Clusters[name]
+		if isManaged(existing.Extensions) {
+			continue
+		}
+
+		for _, cluster := range patch.Clusters {
+			metadata, _ := getPatchMetadata(cluster.Cluster.Extensions)
+			matches := existing.Server == cluster.Cluster.Server ||
+				(metadata.ClusterArn != "" && name == metadata.ClusterArn)
+
+			if matches && name != cluster.Name && adoptable(config.Clusters, cluster.Name, clusterExtensions) {
+				adopted.clusters[name] = cluster
+
+				break
+			}
+		}
+	}
+
+	// Users can only be renamed to a single new name
+	conflictingUsers := make(map[string]struct{})
+	// Only one context is adopted for each generated context, the current-context when it is one of them
+	claimed := make(map[string]struct{})
+
+	names := slices.Sorted(maps.Keys(config.Contexts))
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == config.CurrentContext && names[j] != config.CurrentContext
+	})
+
+	for _, name := range names {
+		existing := config.Contexts[name]
+		cluster, ok := adopted.clusters[existing.Cluster]
+
+		if !ok || isManaged(existing.Extensions) {
+			continue
+		}
+
+		context := primaryContext(patch, cluster.Name)
+		if context == nil {
+			continue
+		}
+
+		_, taken := claimed[context.Name]
+		if !taken && name != context.Name && adoptable(config.Contexts, context.Name, contextExtensions) {
+			adopted.contexts[name] = context
+			claimed[context.Name] = struct{}{}
+		}
+
+		user, ok := config.AuthInfos[existing.AuthInfo]
+		if !ok || isManaged(user.Extensions) || existing.AuthInfo == context.Context.AuthInfo {
+			continue
+		}
+
+		newUser := findPatchUser(patch, context.Context.AuthInfo)
+		if newUser == nil || !adoptable(config.AuthInfos, newUser.Name, userExtensions) {
+			continue
+		}
+
+		if previous, ok := adopted.users[existing.AuthInfo]; ok && previous.Name != newUser.Name {
+			conflictingUsers[existing.AuthInfo] = struct{}{}
+		}
+
+		adopted.users[existing.AuthInfo] = newUser
+	}
+
+	// Users also used for clusters that aren't being adopted keep their names
+	for _, context := range config.Contexts {
+		if _, ok := adopted.clusters[context.Cluster]; !ok && !isManaged(context.Extensions) {
+			conflictingUsers[context.AuthInfo] = struct{}{}
+		}
+	}
+
+	for name := range conflictingUsers {
+		delete(adopted.users, name)
+	}
+
+	return adopted
+}
+
+// adoptEntry renames an entry, marking it as managed with the extensions of the patch entry. When the new name is
+// already managed by gogok8s the entry is removed instead.
+func adoptEntry[T any](
+	entries map[string]*T,
+	oldName, newName string,
+	patchExtensions []v1.NamedExtension,
+	extensions func(*T) *map[string]runtime.Object,
+) []Change {
+	entry := entries[oldName]
+	delete(entries, oldName)
+
+	if _, ok := entries[newName]; ok {
+		return []Change{{Name: oldName, Type: ChangeRemoved}}
+	}
+
+	*extensions(entry) = mergeMetadataExtensions(*extensions(entry), patchExtensions)
+	entries[newName] = entry
+
+	return renameChanges(map[string]string{oldName: newName})
+}
+
+// adoptable returns whether an entry can be adopted under name, unless it is taken by an entry gogok8s didn't create.
+func adoptable[T any](entries map[string]*T, name string, extensions func(*T) map[string]runtime.Object) bool {
+	entry, ok := entries[name]
+
+	return !ok || isManaged(extensions(entry))
+}
+
+// primaryContext returns the context of the patch for the cluster's own user rather than an extra user.
+func primaryContext(patch *KubeConfigPatch, cluster string) *v1.NamedContext {
+	for _, context := range patch.Contexts {
+		if context.Context.Cluster != cluster {
+			continue
+		}
+
+		if metadata, _ := getPatchMetadata(context.Context.Extensions); metadata.ExtraUser == "" {
+			return context
+		}
+	}
+
+	return nil
+}
+
+func findPatchUser(patch *KubeConfigPatch, name string) *v1.NamedAuthInfo {
+	for _, user := range patch.Users {
+		if user.Name == name {
+			return user
+		}
+	}
+
+	return nil
+}
+
+func clusterExtensions(c *api.Cluster) map[string]runtime.Object { return c.Extensions }
+
+func userExtensions(u *api.AuthInfo) map[string]runtime.Object { return u.Extensions }
+
+func contextExtensions(c *api.Context) map[string]runtime.Object { return c.Extensions }
@@ -0,0 +1,90 @@
+package kubecfg_test
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func TestAdopt(t *testing.T) {
+	t.Parallel()
+
+	const arn = "arn:aws:eks:us-east-1:111111111111:cluster/foo"
+
+	patch := newTestPatch("Dev.foo", "https://localhost:7777", "dev")
+	patch.Clusters[0].Cluster.Extensions = kubecfg.NewMetadataExtensions(kubecfg.Metadata{
+		Account: "Dev", Region: "us-east-1", ClusterName: "foo", ClusterArn: arn,
+	})
+
+	// The entries written by `aws eks update-kubeconfig`, along with a second context for the same cluster
+	config := api.NewConfig()
+	config.Clusters[arn] = &api.Cluster{Server: "https://localhost:7777"}
+	config.AuthInfos[arn] = &api.AuthInfo{Exec: &api.ExecConfig{Command: "aws", Args: []string{"eks", "get-token"}}}
+	config.Contexts[arn] = &api.Context{Cluster: arn, AuthInfo: arn, Namespace: "web"}
+	config.Contexts["foo-admin"] = &api.Context{Cluster: arn, AuthInfo: arn}
+	config.CurrentContext = arn
+
+	diff := kubecfg.Adopt(patch, config)
+
+	for _, changes := range [][]kubecfg.Change{diff.Clusters, diff.Users, diff.Contexts} {
+		if findChange(t, changes, arn).Type != kubecfg.ChangeRemoved ||
+			findChange(t, changes, "Dev.foo").Type != kubecfg.ChangeAdded {
+			t.Errorf("expected %s to be renamed to Dev.foo, got %+v", arn, changes)
+		}
+	}
+
+	if config.CurrentContext != "Dev.foo" || diff.CurrentContext == nil {
+		t.Errorf("expected the current-context to follow the rename, got %s", config.CurrentContext)
+	}
+
+	context := config.Contexts["Dev.foo"]
+	if context == nil || context.Namespace != "web" {
+		t.Fatalf("expected the namespace of the adopted context to be kept, got %+v", context)
+	}
+
+	if other := config.Contexts["foo-admin"]; other.Cluster != "Dev.foo" || other.AuthInfo != "Dev.foo" {
+		t.Errorf("expected the remaining context to follow the renamed cluster and user, got %+v", other)
+	}
+
+	// Applying the patch afterwards updates the adopted entries rather than conflicting with them
+	applied := kubecfg.ApplyPatch(patch, config, kubecfg.ApplyOptions{})
+	if len(applied.Conflicts) != 0 || len(config.Clusters) != 1 || len(config.AuthInfos) != 1 {
+		t.Errorf("expected the adopted entries to be updated, got %+v", applied)
+	}
+
+	if config.Contexts["Dev.foo"].Namespace != "web" {
+		t.Error("expected the namespace to be kept after applying the patch")
+	}
+}
+
+func TestAdoptMergesIntoManagedEntries(t *testing.T) {
+	t.Parallel()
+
+	patch := newTestPatch("foo", "https://localhost:7777", "dev")
+
+	// A previous sync already added the generated entries next to the ones of another tool
+	config := api.NewConfig()
+	kubecfg.ApplyPatch(patch, config, kubecfg.ApplyOptions{})
+	config.Clusters["old"] = &api.Cluster{Server: "https://localhost:7777"}
+	config.AuthInfos["old"] = &api.AuthInfo{Token: "token"}
+	config.Contexts["old"] = &api.Context{Cluster: "old", AuthInfo: "old", Namespace: "web"}
+	config.Contexts["unrelated"] = &api.Context{Cluster: "other", AuthInfo: "old"}
+	config.CurrentContext = "old"
+
+	diff := kubecfg.Adopt(patch, config)
+
+	if _, ok := config.Clusters["old"]; ok || findChange(t, diff.Clusters, "old").Type != kubecfg.ChangeRemoved {
+		t.Error("expected the duplicate cluster to be removed")
+	}
+
+	if _, ok := config.Contexts["old"]; ok || config.CurrentContext != "foo" || config.Contexts["foo"].Namespace != "web" {
+		t.Errorf("expected the duplicate context to be merged into foo, got %+v", config.Contexts)
+	}
+
+	// The user is still used by a context of another cluster, so it keeps its name
+	if _, ok := config.AuthInfos["old"]; !ok || len(diff.Users) != 0 {
+		t.Errorf("expected the shared user to be kept, got %+v", diff.Users)
+	}
+}
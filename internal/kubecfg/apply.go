@@ -1,17 +1,179 @@
 package kubecfg
 
 import (
-	"bytes"
+	"fmt"
 
-	"github.com/BigPapaChas/gogok8s/internal/terminal"
 	"k8s.io/client-go/tools/clientcmd/api"
 	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
 )
 
-func applyClusterChanges(config *api.Config, cluster *v1.NamedCluster) {
-	compareClusterChanges(config, cluster)
+// conflictResolution tracks how conflicting clusters and users were resolved, so that the contexts referencing them
+// can be resolved the same way.
+type conflictResolution struct {
+	skippedClusters  map[string]struct{}
+	skippedUsers     map[string]struct{}
+	renamedClusters  map[string]string
+	renamedUsers     map[string]string
+	conflictingNames map[string]struct{}
+}
+
+func newConflictResolution() *conflictResolution {
+	return &conflictResolution{
+		skippedClusters:  make(map[string]struct{}),
+		skippedUsers:     make(map[string]struct{}),
+		renamedClusters:  make(map[string]string),
+		renamedUsers:     make(map[string]string),
+		conflictingNames: make(map[string]struct{}),
+	}
+}
+
+func applyClusters(config *api.Config, patch *KubeConfigPatch, policy ConflictPolicy, res *conflictResolution, diff *Diff) {
+	for _, patchCluster := range patch.Clusters {
+		// Renames are made to a copy, leaving the patch untouched
+		copied := *patchCluster
+		cluster := &copied
+		replace := false
+
+		if existing, ok := config.Clusters[cluster.Name]; ok && clusterConflicts(existing, cluster) {
+			conflict := newConflict(KindCluster, cluster.Name, policy)
+			res.conflictingNames[KindCluster+"/"+cluster.Name] = struct{}{}
+
+			switch policy {
+			case ConflictSkip:
+				res.skippedClusters[cluster.Name] = struct{}{}
+				diff.Conflicts = append(diff.Conflicts, conflict)
+
+				continue
+			case ConflictRename:
+				conflict.RenamedTo = renameTarget(cluster.Name, func(name string) bool {
+					c, ok := config.Clusters[name]
+
+					return !ok || isManaged(c.Extensions)
+				})
+				res.renamedClusters[cluster.Name] = conflict.RenamedTo
+				cluster.Name = conflict.RenamedTo
+			case ConflictOverwrite:
+				replace = true
+			}
+
+			diff.Conflicts = append(diff.Conflicts, conflict)
+		}
+
+		if change := applyClusterChanges(config, cluster, replace); change != nil {
+			diff.Clusters = append(diff.Clusters, *change)
+		}
+	}
+}
+
+func applyUsers(config *api.Config, patch *KubeConfigPatch, policy ConflictPolicy, res *conflictResolution, diff *Diff) {
+	for _, patchUser := range patch.Users {
+		copied := *patchUser
+		user := &copied
+		replace := false
+
+		if existing, ok := config.AuthInfos[user.Name]; ok && userConflicts(existing, user) {
+			conflict := newConflict(KindUser, user.Name, policy)
+			res.conflictingNames[KindUser+"/"+user.Name] = struct{}{}
+
+			switch policy {
+			case ConflictSkip:
+				res.skippedUsers[user.Name] = struct{}{}
+				diff.Conflicts = append(diff.Conflicts, conflict)
+
+				continue
+			case ConflictRename:
+				conflict.RenamedTo = renameTarget(user.Name, func(name string) bool {
+					u, ok := config.AuthInfos[name]
+
+					return !ok || isManaged(u.Extensions)
+				})
+				res.renamedUsers[user.Name] = conflict.RenamedTo
+				user.Name = conflict.RenamedTo
+			case ConflictOverwrite:
+				replace = true
+			}
+
+			diff.Conflicts = append(diff.Conflicts, conflict)
+		}
+
+		if change := applyUserChanges(config, user, replace); change != nil {
+			diff.Users = append(diff.Users, *change)
+		}
+	}
+}
+
+func applyContexts(config *api.Config, patch *KubeConfigPatch, policy ConflictPolicy, res *conflictResolution, diff *Diff) {
+	for _, patchContext := range patch.Contexts {
+		copied := *patchContext
+		context := &copied
+
+		// A context can't be written when the cluster or user it references was skipped
+		if _, ok := res.skippedClusters[context.Context.Cluster]; ok {
+			diff.Conflicts = append(diff.Conflicts, skippedReferenceConflict(context.Name, KindCluster, context.Context.Cluster))
+
+			continue
+		}
+
+		if _, ok := res.skippedUsers[context.Context.AuthInfo]; ok {
+			diff.Conflicts = append(diff.Conflicts, skippedReferenceConflict(context.Name, KindUser, context.Context.AuthInfo))
+
+			continue
+		}
+
+		_, clusterConflicted := res.conflictingNames[KindCluster+"/"+context.Context.Cluster]
+		_, userConflicted := res.conflictingNames[KindUser+"/"+context.Context.AuthInfo]
+		replace := false
 
-	if _, ok := config.Clusters[cluster.Name]; !ok {
+		if existing, ok := config.Contexts[context.Name]; ok && contextConflicts(existing, context, clusterConflicted || userConflicted) {
+			conflict := newConflict(KindContext, context.Name, policy)
+
+			switch policy {
+			case ConflictSkip:
+				diff.Conflicts = append(diff.Conflicts, conflict)
+
+				continue
+			case ConflictRename:
+				conflict.RenamedTo = renameTarget(context.Name, func(name string) bool {
+					c, ok := config.Contexts[name]
+
+					return !ok || isManaged(c.Extensions)
+				})
+				context.Name = conflict.RenamedTo
+			case ConflictOverwrite:
+				replace = true
+			}
+
+			diff.Conflicts = append(diff.Conflicts, conflict)
+		}
+
+		// Point the context at the new names of any renamed cluster or user
+		if renamed, ok := res.renamedClusters[context.Context.Cluster]; ok {
+			context.Context.Cluster = renamed
+		}
+
+		if renamed, ok := res.renamedUsers[context.Context.AuthInfo]; ok {
+			context.Context.AuthInfo = renamed
+		}
+
+		if change := applyContextChanges(config, context, replace); change != nil {
+			diff.Contexts = append(diff.Contexts, *change)
+		}
+	}
+}
+
+func skippedReferenceConflict(name, kind, reference string) Conflict {
+	return Conflict{
+		Kind:       KindContext,
+		Name:       name,
+		Reason:     fmt.Sprintf("references skipped %s %s", kind, reference),
+		Resolution: ConflictSkip,
+	}
+}
+
+func applyClusterChanges(config *api.Config, cluster *v1.NamedCluster, replace bool) *Change {
+	before := clusterFields(config.Clusters[cluster.Name])
+
+	if _, ok := config.Clusters[cluster.Name]; !ok || replace {
 		config.Clusters[cluster.Name] = &api.Cluster{
 			Server:                   cluster.Cluster.Server,
 			CertificateAuthorityData: cluster.Cluster.CertificateAuthorityData,
@@ -20,28 +182,42 @@ func applyClusterChanges(config *api.Config, cluster *v1.NamedCluster) {
 		config.Clusters[cluster.Name].Server = cluster.Cluster.Server
 		config.Clusters[cluster.Name].CertificateAuthorityData = cluster.Cluster.CertificateAuthorityData
 	}
+
+	config.Clusters[cluster.Name].Extensions = mergeMetadataExtensions(
+		config.Clusters[cluster.Name].Extensions,
+		cluster.Cluster.Extensions,
+	)
+
+	return diffEntry(cluster.Name, before, clusterFields(config.Clusters[cluster.Name]))
 }
 
-func applyUserChanges(config *api.Config, user *v1.NamedAuthInfo) {
-	if _, ok := config.AuthInfos[user.Name]; !ok {
-		config.AuthInfos[user.Name] = &api.AuthInfo{
-			Exec: &api.ExecConfig{
-				Command:    user.AuthInfo.Exec.Command,
-				Args:       user.AuthInfo.Exec.Args,
-				Env:        convertExecEnvVar(user.AuthInfo.Exec.Env),
-				APIVersion: user.AuthInfo.Exec.APIVersion,
-			},
-		}
-	} else {
-		config.AuthInfos[user.Name].Exec.Command = user.AuthInfo.Exec.Command
-		config.AuthInfos[user.Name].Exec.Args = user.AuthInfo.Exec.Args
-		config.AuthInfos[user.Name].Exec.Env = convertExecEnvVar(user.AuthInfo.Exec.Env)
-		config.AuthInfos[user.Name].Exec.APIVersion = user.AuthInfo.Exec.APIVersion
+func applyUserChanges(config *api.Config, user *v1.NamedAuthInfo, replace bool) *Change {
+	before := authInfoFields(config.AuthInfos[user.Name])
+
+	if _, ok := config.AuthInfos[user.Name]; !ok || replace {
+		config.AuthInfos[user.Name] = &api.AuthInfo{}
+	}
+
+	if config.AuthInfos[user.Name].Exec == nil {
+		config.AuthInfos[user.Name].Exec = &api.ExecConfig{}
 	}
+
+	config.AuthInfos[user.Name].Exec.Command = user.AuthInfo.Exec.Command
+	config.AuthInfos[user.Name].Exec.Args = user.AuthInfo.Exec.Args
+	config.AuthInfos[user.Name].Exec.Env = convertExecEnvVar(user.AuthInfo.Exec.Env)
+	config.AuthInfos[user.Name].Exec.APIVersion = user.AuthInfo.Exec.APIVersion
+	config.AuthInfos[user.Name].Extensions = mergeMetadataExtensions(
+		config.AuthInfos[user.Name].Extensions,
+		user.AuthInfo.Extensions,
+	)
+
+	return diffEntry(user.Name, before, authInfoFields(config.AuthInfos[user.Name]))
 }
 
-func applyContextChanges(config *api.Config, context *v1.NamedContext) {
-	if _, ok := config.Contexts[context.Name]; !ok {
+func applyContextChanges(config *api.Config, context *v1.NamedContext, replace bool) *Change {
+	before := contextFields(config.Contexts[context.Name])
+
+	if _, ok := config.Contexts[context.Name]; !ok || replace {
 		config.Contexts[context.Name] = &api.Context{
 			Cluster:  context.Context.Cluster,
 			AuthInfo: context.Context.AuthInfo,
@@ -50,29 +226,13 @@ func applyContextChanges(config *api.Config, context *v1.NamedContext) {
 		config.Contexts[context.Name].Cluster = context.Context.Cluster
 		config.Contexts[context.Name].AuthInfo = context.Context.AuthInfo
 	}
-}
 
-func compareClusterChanges(config *api.Config, cluster *v1.NamedCluster) bool {
-	if currentConfig, ok := config.Clusters[cluster.Name]; !ok {
-		terminal.DiffAdd(cluster.Name)
-
-		return true
-	} else if currentConfig.Server != cluster.Cluster.Server ||
-		!bytes.Equal(currentConfig.CertificateAuthorityData, cluster.Cluster.CertificateAuthorityData) {
-		terminal.DiffModify(cluster.Name)
-		if currentConfig.Server != cluster.Cluster.Server {
-			terminal.DiffMinus(currentConfig.Server)
-			terminal.DiffAdd(cluster.Cluster.Server)
-		}
-		if !bytes.Equal(currentConfig.CertificateAuthorityData, cluster.Cluster.CertificateAuthorityData) {
-			terminal.DiffMinus("certificate-authority-data: <OMITTED>")
-			terminal.DiffAdd("certificate-authority-data: <OMITTED>")
-		}
-
-		return true
-	}
+	config.Contexts[context.Name].Extensions = mergeMetadataExtensions(
+		config.Contexts[context.Name].Extensions,
+		context.Context.Extensions,
+	)
 
-	return false
+	return diffEntry(context.Name, before, contextFields(config.Contexts[context.Name]))
 }
 
 func convertExecEnvVar(envVars []v1.ExecEnvVar) []api.ExecEnvVar {
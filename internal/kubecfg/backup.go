@@ -0,0 +1,253 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// DefaultBackupRetention is the number of kubeconfig backups kept when no retention is configured.
+	DefaultBackupRetention = 10
+
+	backupDirName     = "gogok8s-backups"
+	backupFilePrefix  = "config-"
+	backupTimeFormat  = "20060102T150405.000000000Z"
+	backupDirFilemode = os.FileMode(0o700)
+	backupFilemode    = os.FileMode(0o600)
+
+	// The format of backups taken before timestamps had sub-second precision.
+	legacyBackupTimeFormat = "20060102T150405Z"
+)
+
+var (
+	ErrBackupNotFound = errors.New("kubeconfig backup not found")
+	ErrNoBackups      = errors.New("no kubeconfig backups found")
+)
+
+type Backup struct {
+	Timestamp string
+	Time      time.Time
+	Path      string
+}
+
+// Load reads the kubeconfig stored within the backup.
+func (b Backup) Load() (*api.Config, error) {
+	return LoadFromFile(b.Path)
+}
+
+// BackupDefault copies the current kubeconfig into the backup directory, removing the oldest backups so that at most
+// retention backups are kept. A nil backup is returned when there is no kubeconfig to back up.
+func BackupDefault(retention int) (*Backup, error) {
+	filename, err := getKubeConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	return backupKubeConfig(resolveKubeConfigPath(filename), retention)
+}
+
+// ListBackups returns all kubeconfig backups, newest first.
+func ListBackups() ([]Backup, error) {
+	dir, err := getBackupDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return listBackups(dir)
+}
+
+// FindBackup returns the backup taken at the given timestamp.
+func FindBackup(timestamp string) (Backup, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	for _, backup := range backups {
+		if backup.Timestamp == timestamp {
+			return backup, nil
+		}
+	}
+
+	return Backup{}, fmt.Errorf("%w: %s", ErrBackupNotFound, timestamp)
+}
+
+// LatestBackup returns the most recent backup.
+func LatestBackup() (Backup, error) {
+	backups, err := ListBackups()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	if len(backups) == 0 {
+		return Backup{}, ErrNoBackups
+	}
+
+	return backups[0], nil
+}
+
+func backupKubeConfig(filename string, retention int) (*Backup, error) {
+	dir, err := getBackupDir()
+	if err != nil {
+		return nil, err
+	}
+
+	return backupFile(filename, dir, retention, time.Now())
+}
+
+func backupFile(filename, dir string, retention int, now time.Time) (*Backup, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil, nil //nolint:nilnil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig for backup: %w", err)
+	}
+
+	if err = os.MkdirAll(dir, backupDirFilemode); err != nil {
+		return nil, fmt.Errorf("failed to create kubeconfig backup directory: %w", err)
+	}
+
+	backup, err := writeBackup(dir, data, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = pruneBackups(dir, retention); err != nil {
+		return backup, err
+	}
+
+	return backup, nil
+}
+
+// writeBackup writes the data to a new backup named after now, see WriteTimestamped.
+func writeBackup(dir string, data []byte, now time.Time) (*Backup, error) {
+	path, backupTime, err := WriteTimestamped(func(timestamp string) string {
+		return filepath.Join(dir, backupFilePrefix+timestamp)
+	}, data, backupFilemode, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write kubeconfig backup: %w", err)
+	}
+
+	return &Backup{Timestamp: backupTime.Format(backupTimeFormat), Time: backupTime, Path: path}, nil
+}
+
+// WriteTimestamped writes data to a new file, whose name is returned by name for the timestamp of now. When a file was
+// already written at that time it moves on to the next nanosecond, so that an earlier file is never overwritten. The
+// path of the file and the time it is named after are returned.
+func WriteTimestamped(
+	name func(timestamp string) string,
+	data []byte,
+	perm os.FileMode,
+	now time.Time,
+) (string, time.Time, error) {
+	for {
+		path := name(now.Format(backupTimeFormat))
+
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if err != nil && errors.Is(err, os.ErrExist) {
+			now = now.Add(time.Nanosecond)
+
+			continue
+		} else if err != nil {
+			return "", now, err
+		}
+
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return "", now, err
+		}
+
+		return path, now, nil
+	}
+}
+
+// parseBackupTime parses the timestamp of a backup, returning false when it isn't one.
+func parseBackupTime(timestamp string) (time.Time, bool) {
+	for _, format := range []string{backupTimeFormat, legacyBackupTimeFormat} {
+		if backupTime, err := time.Parse(format, timestamp); err == nil {
+			return backupTime, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+func listBackups(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig backup directory: %w", err)
+	}
+
+	var backups []Backup
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), backupFilePrefix) {
+			continue
+		}
+
+		timestamp := strings.TrimPrefix(entry.Name(), backupFilePrefix)
+
+		backupTime, ok := parseBackupTime(timestamp)
+		if !ok {
+			// Not a backup created by gogok8s, ignore it
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Timestamp: timestamp,
+			Time:      backupTime,
+			Path:      filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+func pruneBackups(dir string, retention int) error {
+	if retention <= 0 {
+		retention = DefaultBackupRetention
+	}
+
+	backups, err := listBackups(dir)
+	if err != nil {
+		return err
+	}
+
+	if len(backups) <= retention {
+		return nil
+	}
+
+	for _, backup := range backups[retention:] {
+		if err := os.Remove(backup.Path); err != nil {
+			return fmt.Errorf("failed to remove old kubeconfig backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func getBackupDir() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user home directory: %w", err)
+	}
+
+	return filepath.Join(homedir, ".kube", backupDirName), nil
+}
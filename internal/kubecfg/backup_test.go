@@ -0,0 +1,110 @@
+package kubecfg_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+const testKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: foo
+  cluster:
+    server: https://localhost:7777
+contexts: []
+users: []
+current-context: ""
+`
+
+func TestBackupRetention(t *testing.T) {
+	home := t.TempDir()
+	kubeconfig := filepath.Join(home, "config")
+
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	// Backing up a kubeconfig that does not exist is a no-op
+	backup, err := kubecfg.BackupDefault(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if backup != nil {
+		t.Fatalf("BackupDefault() returned backup %s for a missing kubeconfig", backup.Path)
+	}
+
+	if err = os.WriteFile(kubeconfig, []byte(testKubeConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err = kubecfg.BackupDefault(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := backup.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := restored.Clusters["foo"]; !ok {
+		t.Fatal("backup does not contain cluster foo")
+	}
+
+	// Create extra backups with older timestamps that should be pruned by the next backup
+	dir := filepath.Dir(backup.Path)
+	for _, name := range []string{"config-20200101T000000Z", "config-20210101T000000Z"} {
+		if err = os.WriteFile(filepath.Join(dir, name), []byte(testKubeConfig), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err = kubecfg.BackupDefault(2); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := kubecfg.ListBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backups) != 2 {
+		t.Fatalf("ListBackups() returned %d backups, but expected %d", len(backups), 2)
+	}
+
+	latest, err := kubecfg.LatestBackup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latest.Timestamp != backups[0].Timestamp || latest.Timestamp == "20210101T000000Z" {
+		t.Errorf("LatestBackup() returned %s, expected the newest backup", latest.Timestamp)
+	}
+
+	if _, err = kubecfg.FindBackup("20200101T000000Z"); err == nil {
+		t.Error("FindBackup() found a backup that should have been pruned")
+	}
+
+	// Backups taken in quick succession never overwrite each other
+	first, err := kubecfg.BackupDefault(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := kubecfg.BackupDefault(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Timestamp == second.Timestamp {
+		t.Fatalf("expected two backups, both were named %s", first.Timestamp)
+	}
+
+	if found, err := kubecfg.FindBackup(first.Timestamp); err != nil || !found.Time.Equal(first.Time) {
+		t.Errorf("FindBackup() didn't find backup %s: %v", first.Timestamp, err)
+	}
+}
@@ -0,0 +1,136 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+type CollisionPolicy string
+
+const (
+	// CollisionError refuses to apply a patch holding colliding names.
+	CollisionError CollisionPolicy = "error"
+	// CollisionSkip leaves out every entry generated for the clusters involved in a collision.
+	CollisionSkip CollisionPolicy = "skip"
+)
+
+//nolint:gochecknoglobals
+var CollisionPolicies = []CollisionPolicy{CollisionError, CollisionSkip}
+
+var ErrInvalidCollisionPolicy = errors.New("invalid collision policy")
+
+// Collision describes a name generated for more than one entry of a patch, e.g. by two accounts with the same format
+// and a cluster of the same name. Which entry ends up in the kubeconfig would depend on the order they're applied in.
+type Collision struct {
+	Kind    string     `json:"kind" yaml:"kind"`
+	Name    string     `json:"name" yaml:"name"`
+	Sources []Metadata `json:"sources" yaml:"sources"`
+}
+
+// ParseCollisionPolicy validates a collision policy, defaulting to CollisionError when empty.
+func ParseCollisionPolicy(value string) (CollisionPolicy, error) {
+	if value == "" {
+		return CollisionError, nil
+	}
+
+	for _, policy := range CollisionPolicies {
+		if CollisionPolicy(value) == policy {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidCollisionPolicy, value)
+}
+
+// FindCollisions returns every name used by more than one generated cluster, user or context of the patch, sorted by
+// kind and name.
+func FindCollisions(patch *KubeConfigPatch) []Collision {
+	var collisions []Collision
+
+	clusters := make(map[string][]Metadata)
+	for _, cluster := range patch.Clusters {
+		addCollisionSource(clusters, cluster.Name, cluster.Cluster.Extensions)
+	}
+
+	users := make(map[string][]Metadata)
+	for _, user := range patch.Users {
+		addCollisionSource(users, user.Name, user.AuthInfo.Extensions)
+	}
+
+	contexts := make(map[string][]Metadata)
+	for _, context := range patch.Contexts {
+		addCollisionSource(contexts, context.Name, context.Context.Extensions)
+	}
+
+	kinds := []string{KindCluster, KindUser, KindContext}
+	for idx, sources := range []map[string][]Metadata{clusters, users, contexts} {
+		for name, metadata := range sources {
+			if len(metadata) < 2 {
+				continue
+			}
+
+			sort.Slice(metadata, func(i, j int) bool {
+				return metadataKey(metadata[i]) < metadataKey(metadata[j])
+			})
+
+			collisions = append(collisions, Collision{Kind: kinds[idx], Name: name, Sources: metadata})
+		}
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		if collisions[i].Kind != collisions[j].Kind {
+			return collisions[i].Kind < collisions[j].Kind
+		}
+
+		return collisions[i].Name < collisions[j].Name
+	})
+
+	return collisions
+}
+
+// addCollisionSource records the metadata of an entry under its name, ignoring identical entries.
+func addCollisionSource(sources map[string][]Metadata, name string, extensions []v1.NamedExtension) {
+	metadata, _ := getPatchMetadata(extensions)
+
+	for _, existing := range sources[name] {
+		if existing == metadata {
+			return
+		}
+	}
+
+	sources[name] = append(sources[name], metadata)
+}
+
+// RemoveCollisions returns the patch without any of the entries generated for the clusters involved in the collisions,
+// so that no colliding name is applied and no context is left pointing at a missing cluster or user.
+func RemoveCollisions(patch *KubeConfigPatch, collisions []Collision) *KubeConfigPatch {
+	if len(collisions) == 0 {
+		return patch
+	}
+
+	colliding := make(map[string]struct{})
+
+	for _, collision := range collisions {
+		for _, source := range collision.Sources {
+			colliding[clusterKey(source)] = struct{}{}
+		}
+	}
+
+	return FilterPatch(patch, func(name string, metadata Metadata) bool {
+		_, ok := colliding[clusterKey(metadata)]
+
+		return !ok
+	})
+}
+
+// clusterKey identifies the cluster an entry was generated for, regardless of the extra user.
+func clusterKey(metadata Metadata) string {
+	return metadata.Account + "\x00" + metadata.Region + "\x00" + metadata.ClusterName + "\x00" + metadata.ClusterArn
+}
+
+func metadataKey(metadata Metadata) string {
+	return clusterKey(metadata) + "\x00" + metadata.ExtraUser
+}
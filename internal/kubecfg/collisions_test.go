@@ -0,0 +1,50 @@
+package kubecfg_test
+
+import (
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func TestFindAndRemoveCollisions(t *testing.T) {
+	t.Parallel()
+
+	patch := newTestPatch("foo", "https://localhost:7777", "dev")
+	other := newTestPatch("foo", "https://localhost:8888", "staging")
+	unrelated := newTestPatch("bar", "https://localhost:9999", "dev")
+
+	// The same cluster name within another account, generating the same names under a format without ${name}
+	metadata := kubecfg.NewMetadataExtensions(kubecfg.Metadata{Account: "Staging", Region: "us-east-1", ClusterName: "foo"})
+	other.Clusters[0].Cluster.Extensions = metadata
+	other.Users[0].AuthInfo.Extensions = metadata
+	other.Contexts[0].Context.Extensions = metadata
+
+	for _, p := range []*kubecfg.KubeConfigPatch{other, unrelated} {
+		patch.Clusters = append(patch.Clusters, p.Clusters...)
+		patch.Users = append(patch.Users, p.Users...)
+		patch.Contexts = append(patch.Contexts, p.Contexts...)
+	}
+
+	collisions := kubecfg.FindCollisions(patch)
+	if len(collisions) != 3 {
+		t.Fatalf("expected the cluster, user and context foo to collide, got %+v", collisions)
+	}
+
+	if collisions[0].Kind != kubecfg.KindCluster || collisions[0].Name != "foo" || len(collisions[0].Sources) != 2 {
+		t.Errorf("expected cluster foo to be generated by two accounts, got %+v", collisions[0])
+	}
+
+	if collisions[0].Sources[0].Account != "Dev" || collisions[0].Sources[1].Account != "Staging" {
+		t.Errorf("expected the sources to be sorted by account, got %+v", collisions[0].Sources)
+	}
+
+	filtered := kubecfg.RemoveCollisions(patch, collisions)
+	if len(filtered.Clusters) != 1 || len(filtered.Users) != 1 || len(filtered.Contexts) != 1 ||
+		filtered.Contexts[0].Name != "bar" {
+		t.Errorf("expected only the entries of bar to be left, got %+v", filtered)
+	}
+
+	if len(kubecfg.FindCollisions(filtered)) != 0 {
+		t.Error("expected no collisions after removing them")
+	}
+}
@@ -0,0 +1,58 @@
+package kubecfg
+
+import (
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// CompareConfigs returns the changes required to turn the current kubeconfig into the target kubeconfig.
+func CompareConfigs(current, target *api.Config) *Diff {
+	diff := &Diff{
+		Clusters: compareEntries(current.Clusters, target.Clusters, clusterFields),
+		Users:    compareEntries(current.AuthInfos, target.AuthInfos, authInfoFields),
+		Contexts: compareEntries(current.Contexts, target.Contexts, contextFields),
+	}
+
+	if current.CurrentContext != target.CurrentContext {
+		diff.CurrentContext = &FieldChange{
+			Field: "current-context",
+			Old:   current.CurrentContext,
+			New:   target.CurrentContext,
+		}
+	}
+
+	return diff
+}
+
+func compareEntries[T any](current, target map[string]*T, fields func(*T) []field) []Change {
+	var changes []Change
+
+	names := make(map[string]struct{})
+	for name := range current {
+		names[name] = struct{}{}
+	}
+
+	for name := range target {
+		names[name] = struct{}{}
+	}
+
+	for _, name := range sortedKeys(names) {
+		if change := diffEntry(name, fields(current[name]), fields(target[name])); change != nil {
+			changes = append(changes, *change)
+		}
+	}
+
+	return changes
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
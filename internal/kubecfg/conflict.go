@@ -0,0 +1,102 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+type ConflictPolicy string
+
+const (
+	// ConflictSkip leaves the existing entry untouched and does not write the generated entry.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing entry with the generated entry.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictRename leaves the existing entry untouched and writes the generated entry under a new name.
+	ConflictRename ConflictPolicy = "rename"
+
+	KindCluster = "cluster"
+	KindUser    = "user"
+	KindContext = "context"
+
+	renameSuffix = ".gogok8s"
+)
+
+//nolint:gochecknoglobals
+var ConflictPolicies = []ConflictPolicy{ConflictSkip, ConflictOverwrite, ConflictRename}
+
+var ErrInvalidConflictPolicy = errors.New("invalid conflict policy")
+
+// Conflict describes a generated entry whose name collides with an existing entry that gogok8s did not create.
+type Conflict struct {
+	Kind       string         `json:"kind" yaml:"kind"`
+	Name       string         `json:"name" yaml:"name"`
+	Reason     string         `json:"reason" yaml:"reason"`
+	Resolution ConflictPolicy `json:"resolution" yaml:"resolution"`
+	RenamedTo  string         `json:"renamedTo,omitempty" yaml:"renamedTo,omitempty"`
+}
+
+// ParseConflictPolicy validates a conflict policy, defaulting to ConflictSkip when empty.
+func ParseConflictPolicy(value string) (ConflictPolicy, error) {
+	if value == "" {
+		return ConflictSkip, nil
+	}
+
+	for _, policy := range ConflictPolicies {
+		if ConflictPolicy(value) == policy {
+			return policy, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrInvalidConflictPolicy, value)
+}
+
+// The following functions decide whether an existing entry collides with a generated one. Entries carrying gogok8s
+// metadata never collide. Entries written by older versions of gogok8s have no metadata, so they are recognised by
+// having the same shape as a generated entry instead.
+
+func clusterConflicts(existing *api.Cluster, cluster *v1.NamedCluster) bool {
+	return !isManaged(existing.Extensions) && existing.Server != cluster.Cluster.Server
+}
+
+func userConflicts(existing *api.AuthInfo, user *v1.NamedAuthInfo) bool {
+	if isManaged(existing.Extensions) {
+		return false
+	}
+
+	return existing.Exec == nil || user.AuthInfo.Exec == nil || existing.Exec.Command != user.AuthInfo.Exec.Command
+}
+
+func contextConflicts(existing *api.Context, context *v1.NamedContext, referencesConflict bool) bool {
+	if isManaged(existing.Extensions) {
+		return false
+	}
+
+	return referencesConflict ||
+		existing.Cluster != context.Context.Cluster ||
+		existing.AuthInfo != context.Context.AuthInfo
+}
+
+// renameTarget returns the name a conflicting entry is renamed to, reusing a name gogok8s already manages so that
+// repeated syncs keep writing to the same entry.
+func renameTarget(name string, available func(name string) bool) string {
+	candidate := name + renameSuffix
+
+	for idx := 2; !available(candidate); idx++ {
+		candidate = fmt.Sprintf("%s%s-%d", name, renameSuffix, idx)
+	}
+
+	return candidate
+}
+
+func newConflict(kind, name string, policy ConflictPolicy) Conflict {
+	return Conflict{
+		Kind:       kind,
+		Name:       name,
+		Reason:     fmt.Sprintf("existing %s is not managed by gogok8s", kind),
+		Resolution: policy,
+	}
+}
@@ -0,0 +1,92 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+var ErrContextNotFound = errors.New("context not found")
+
+// ManagedContext is a kubeconfig context created by gogok8s, along with the metadata it was generated from.
+type ManagedContext struct {
+	Name      string
+	Namespace string
+	Metadata  Metadata
+}
+
+// ListManagedContexts returns every context within the kubeconfig that gogok8s manages, sorted by account, region and
+// then context name.
+func ListManagedContexts(config *api.Config) []ManagedContext {
+	var contexts []ManagedContext
+
+	for name, context := range config.Contexts {
+		metadata, ok := GetMetadata(context.Extensions)
+		if !ok {
+			continue
+		}
+
+		contexts = append(contexts, ManagedContext{
+			Name:      name,
+			Namespace: context.Namespace,
+			Metadata:  metadata,
+		})
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		a, b := contexts[i], contexts[j]
+		if a.Metadata.Account != b.Metadata.Account {
+			return a.Metadata.Account < b.Metadata.Account
+		}
+
+		if a.Metadata.Region != b.Metadata.Region {
+			return a.Metadata.Region < b.Metadata.Region
+		}
+
+		return a.Name < b.Name
+	})
+
+	return contexts
+}
+
+// SetCurrentContext switches the current-context of the kubeconfig, optionally setting the default namespace of the
+// context as well.
+func SetCurrentContext(config *api.Config, name, namespace string) error {
+	context, ok := config.Contexts[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+
+	config.CurrentContext = name
+
+	if namespace != "" {
+		context.Namespace = namespace
+	}
+
+	return nil
+}
+
+// ScopeToContext returns a kubeconfig holding only the context, along with its cluster and user, set as the
+// current-context.
+func ScopeToContext(config *api.Config, name string) (*api.Config, error) {
+	context, ok := config.Contexts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrContextNotFound, name)
+	}
+
+	scoped := api.NewConfig()
+	scoped.Contexts[name] = context
+	scoped.CurrentContext = name
+
+	if cluster, ok := config.Clusters[context.Cluster]; ok {
+		scoped.Clusters[context.Cluster] = cluster
+	}
+
+	if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok {
+		scoped.AuthInfos[context.AuthInfo] = authInfo
+	}
+
+	return scoped, nil
+}
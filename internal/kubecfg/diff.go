@@ -0,0 +1,216 @@
+package kubecfg
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+type ChangeType string
+
+const (
+	ChangeAdded    ChangeType = "added"
+	ChangeModified ChangeType = "modified"
+	ChangeRemoved  ChangeType = "removed"
+
+	omitted = "<OMITTED>"
+)
+
+// Diff describes every change made to (or planned for) a kubeconfig.
+type Diff struct {
+	CurrentContext *FieldChange `json:"currentContext,omitempty" yaml:"currentContext,omitempty"`
+	Clusters       []Change     `json:"clusters,omitempty" yaml:"clusters,omitempty"`
+	Users          []Change     `json:"users,omitempty" yaml:"users,omitempty"`
+	Contexts       []Change     `json:"contexts,omitempty" yaml:"contexts,omitempty"`
+	Conflicts      []Conflict   `json:"conflicts,omitempty" yaml:"conflicts,omitempty"`
+}
+
+// Change describes an added, modified or removed cluster, user or context.
+type Change struct {
+	Name   string        `json:"name" yaml:"name"`
+	Type   ChangeType    `json:"type" yaml:"type"`
+	Fields []FieldChange `json:"fields,omitempty" yaml:"fields,omitempty"`
+}
+
+// FieldChange describes a single field of an entry that changed. Old is empty for added entries.
+type FieldChange struct {
+	Field string `json:"field" yaml:"field"`
+	Old   string `json:"old,omitempty" yaml:"old,omitempty"`
+	New   string `json:"new,omitempty" yaml:"new,omitempty"`
+}
+
+type field struct {
+	name   string
+	value  string
+	secret bool
+}
+
+// Empty returns whether the diff contains no changes. Conflicts are not changes, so they are not considered.
+func (d *Diff) Empty() bool {
+	return d.CurrentContext == nil && len(d.Clusters) == 0 && len(d.Users) == 0 && len(d.Contexts) == 0
+}
+
+// Merge appends the changes of other to the diff.
+func (d *Diff) Merge(other *Diff) {
+	if other.CurrentContext != nil {
+		d.CurrentContext = other.CurrentContext
+	}
+
+	d.Clusters = append(d.Clusters, other.Clusters...)
+	d.Users = append(d.Users, other.Users...)
+	d.Contexts = append(d.Contexts, other.Contexts...)
+	d.Conflicts = append(d.Conflicts, other.Conflicts...)
+	d.sort()
+}
+
+func (d *Diff) sort() {
+	for _, changes := range [][]Change{d.Clusters, d.Users, d.Contexts} {
+		sort.SliceStable(changes, func(i, j int) bool {
+			return changes[i].Name < changes[j].Name
+		})
+	}
+
+	sort.SliceStable(d.Conflicts, func(i, j int) bool {
+		if d.Conflicts[i].Kind != d.Conflicts[j].Kind {
+			return d.Conflicts[i].Kind < d.Conflicts[j].Kind
+		}
+
+		return d.Conflicts[i].Name < d.Conflicts[j].Name
+	})
+}
+
+// diffEntry compares the fields of an entry before and after a change, returning nil when nothing changed.
+func diffEntry(name string, before, after []field) *Change {
+	if before == nil {
+		var fields []FieldChange
+
+		for _, f := range after {
+			if f.value != "" {
+				fields = append(fields, FieldChange{Field: f.name, New: f.display()})
+			}
+		}
+
+		return &Change{Name: name, Type: ChangeAdded, Fields: fields}
+	}
+
+	if after == nil {
+		return &Change{Name: name, Type: ChangeRemoved}
+	}
+
+	var fields []FieldChange
+
+	for idx := range after {
+		if before[idx].value != after[idx].value {
+			fields = append(fields, FieldChange{
+				Field: after[idx].name,
+				Old:   before[idx].display(),
+				New:   after[idx].display(),
+			})
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &Change{Name: name, Type: ChangeModified, Fields: fields}
+}
+
+func (f field) display() string {
+	if f.secret && f.value != "" {
+		return omitted
+	}
+
+	return f.value
+}
+
+// The following functions flatten each kind of entry into an ordered list of fields so that entries can be compared
+// field by field. Both lists for an entry always contain the same fields in the same order.
+
+func clusterFields(cluster *api.Cluster) []field {
+	if cluster == nil {
+		return nil
+	}
+
+	return []field{
+		{name: "server", value: cluster.Server},
+		{name: "certificate-authority", value: cluster.CertificateAuthority},
+		{name: "certificate-authority-data", value: bytesValue(cluster.CertificateAuthorityData), secret: true},
+		{name: "insecure-skip-tls-verify", value: boolValue(cluster.InsecureSkipTLSVerify)},
+		{name: "tls-server-name", value: cluster.TLSServerName},
+		{name: "proxy-url", value: cluster.ProxyURL},
+	}
+}
+
+func authInfoFields(authInfo *api.AuthInfo) []field {
+	if authInfo == nil {
+		return nil
+	}
+
+	fields := []field{
+		{name: "client-certificate", value: authInfo.ClientCertificate},
+		{name: "client-certificate-data", value: bytesValue(authInfo.ClientCertificateData), secret: true},
+		{name: "client-key", value: authInfo.ClientKey},
+		{name: "client-key-data", value: bytesValue(authInfo.ClientKeyData), secret: true},
+		{name: "token", value: authInfo.Token, secret: true},
+		{name: "tokenFile", value: authInfo.TokenFile},
+		{name: "username", value: authInfo.Username},
+		{name: "password", value: authInfo.Password, secret: true},
+	}
+
+	var authProvider string
+	if authInfo.AuthProvider != nil {
+		authProvider = authInfo.AuthProvider.Name
+	}
+
+	fields = append(fields, field{name: "auth-provider", value: authProvider})
+
+	var command, args, env, apiVersion string
+
+	if authInfo.Exec != nil {
+		command = authInfo.Exec.Command
+		args = strings.Join(authInfo.Exec.Args, " ")
+		apiVersion = authInfo.Exec.APIVersion
+
+		envVars := make([]string, 0, len(authInfo.Exec.Env))
+		for _, envVar := range authInfo.Exec.Env {
+			envVars = append(envVars, envVar.Name+"="+envVar.Value)
+		}
+
+		env = strings.Join(envVars, " ")
+	}
+
+	return append(fields,
+		field{name: "exec.command", value: command},
+		field{name: "exec.args", value: args},
+		field{name: "exec.env", value: env},
+		field{name: "exec.apiVersion", value: apiVersion},
+	)
+}
+
+func contextFields(context *api.Context) []field {
+	if context == nil {
+		return nil
+	}
+
+	return []field{
+		{name: "cluster", value: context.Cluster},
+		{name: "user", value: context.AuthInfo},
+		{name: "namespace", value: context.Namespace},
+	}
+}
+
+// Only equality matters for byte fields, which are always displayed as omitted.
+func bytesValue(data []byte) string {
+	return string(data)
+}
+
+func boolValue(b bool) string {
+	if !b {
+		return ""
+	}
+
+	return strconv.FormatBool(b)
+}
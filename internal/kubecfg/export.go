@@ -0,0 +1,82 @@
+package kubecfg
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+// NewConfigFromPatch builds a standalone kubeconfig holding only the entries of the patch. The current-context is left
+// unset when currentContext is empty.
+func NewConfigFromPatch(patch *KubeConfigPatch, currentContext string) (*api.Config, error) {
+	config := api.NewConfig()
+	ApplyPatch(patch, config, ApplyOptions{})
+
+	if currentContext != "" {
+		if err := SetCurrentContext(config, currentContext, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// FilterPatch returns a patch holding the clusters that keep returns true for, along with the contexts that reference
+// them and the users of those contexts.
+func FilterPatch(patch *KubeConfigPatch, keep func(name string, metadata Metadata) bool) *KubeConfigPatch {
+	filtered := &KubeConfigPatch{}
+	clusters := make(map[string]struct{})
+	users := make(map[string]struct{})
+
+	for _, cluster := range patch.Clusters {
+		metadata, _ := getPatchMetadata(cluster.Cluster.Extensions)
+		if keep(cluster.Name, metadata) {
+			filtered.Clusters = append(filtered.Clusters, cluster)
+			clusters[cluster.Name] = struct{}{}
+		}
+	}
+
+	for _, context := range patch.Contexts {
+		if _, ok := clusters[context.Context.Cluster]; ok {
+			filtered.Contexts = append(filtered.Contexts, context)
+			users[context.Context.AuthInfo] = struct{}{}
+		}
+	}
+
+	for _, user := range patch.Users {
+		if _, ok := users[user.Name]; ok {
+			filtered.Users = append(filtered.Users, user)
+		}
+	}
+
+	return filtered
+}
+
+// WriteToFile writes the kubeconfig to filename, which doesn't have to be the kubeconfig gogok8s manages.
+func WriteToFile(config *api.Config, filename string) error {
+	if err := writeFileAtomic(config, resolveKubeConfigPath(filename)); err != nil {
+		return fmt.Errorf("failed to write kubeconfig to %s: %w", filename, err)
+	}
+
+	return nil
+}
+
+func getPatchMetadata(extensions []v1.NamedExtension) (Metadata, bool) {
+	var metadata Metadata
+
+	for _, extension := range extensions {
+		if extension.Name != metadataExtensionName {
+			continue
+		}
+
+		if err := json.Unmarshal(extension.Extension.Raw, &metadata); err != nil {
+			return metadata, false
+		}
+
+		return metadata, true
+	}
+
+	return metadata, false
+}
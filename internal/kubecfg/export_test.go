@@ -0,0 +1,39 @@
+package kubecfg_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func TestNewConfigFromPatch(t *testing.T) {
+	t.Parallel()
+
+	patch := newTestPatch("foo", "https://localhost:7777", "dev")
+	other := newTestPatch("bar", "https://localhost:8888", "dev")
+	patch.Clusters = append(patch.Clusters, other.Clusters...)
+	patch.Users = append(patch.Users, other.Users...)
+	patch.Contexts = append(patch.Contexts, other.Contexts...)
+
+	filtered := kubecfg.FilterPatch(patch, func(name string, metadata kubecfg.Metadata) bool {
+		return metadata.ClusterName == "foo"
+	})
+
+	config, err := kubecfg.NewConfigFromPatch(filtered, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(config.Clusters) != 1 || len(config.AuthInfos) != 1 || len(config.Contexts) != 1 {
+		t.Errorf("expected only the entries of foo, got %+v", config)
+	}
+
+	if config.CurrentContext != "foo" {
+		t.Errorf("expected current-context foo, got %s", config.CurrentContext)
+	}
+
+	if _, err = kubecfg.NewConfigFromPatch(filtered, "bar"); !errors.Is(err, kubecfg.ErrContextNotFound) {
+		t.Errorf("expected ErrContextNotFound for a filtered context, got %v", err)
+	}
+}
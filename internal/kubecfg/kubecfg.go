@@ -1,6 +1,7 @@
 package kubecfg
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -9,8 +10,6 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
-
-	"github.com/BigPapaChas/gogok8s/internal/terminal"
 )
 
 type KubeConfigPatch struct {
@@ -19,6 +18,11 @@ type KubeConfigPatch struct {
 	Contexts []*v1.NamedContext
 }
 
+// DefaultPath returns the path of the kubeconfig gogok8s reads and writes.
+func DefaultPath() (string, error) {
+	return getKubeConfigFilePath()
+}
+
 func LoadDefault() (*api.Config, error) {
 	filename, err := getKubeConfigFilePath()
 	if err != nil {
@@ -45,35 +49,129 @@ func Write(config *api.Config) error {
 		return err
 	}
 
-	if err = clientcmd.WriteToFile(*config, filename); err != nil {
+	// The kubeconfig is locked at its configured path like client-go does, while a symlink is followed for the write
+	unlock, err := lockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err = writeFileAtomic(config, resolveKubeConfigPath(filename)); err != nil {
 		return fmt.Errorf("failed to write kubeconfig to file: %w", err)
 	}
 
 	return nil
 }
 
-func ApplyPatch(patch *KubeConfigPatch, config *api.Config, purge bool) {
-	if patch == nil {
-		return
+// Update loads the kubeconfig while holding its lock, backs it up, passes it to fn for modification and then writes the
+// result. Holding the lock for the whole read-modify-write keeps concurrent runs of gogok8s or kubectl from clobbering
+// each other's changes. When fn leaves the kubeconfig unchanged, neither a backup is taken nor the file written.
+func Update(backupRetention int, fn func(config *api.Config) error) error {
+	return update(true, backupRetention, fn)
+}
+
+// Modify is the same as Update, but without taking a backup. It is meant for small changes such as switching the
+// current-context, which would otherwise push useful backups out of the retention window.
+func Modify(fn func(config *api.Config) error) error {
+	return update(false, 0, fn)
+}
+
+func update(backup bool, backupRetention int, fn func(config *api.Config) error) error {
+	filename, err := getKubeConfigFilePath()
+	if err != nil {
+		return err
 	}
 
-	terminal.TextYellow("\nApplying changes to kubeconfig")
+	// The kubeconfig is locked at its configured path like client-go does, while a symlink is followed for the write
+	unlock, err := lockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	filename = resolveKubeConfigPath(filename)
 
-	for _, cluster := range patch.Clusters {
-		applyClusterChanges(config, cluster)
+	config, err := LoadFromFile(filename)
+	if err != nil {
+		return err
+	}
+
+	before, err := clientcmd.Write(*config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
 	}
 
-	for _, user := range patch.Users {
-		applyUserChanges(config, user)
+	if err = fn(config); err != nil {
+		return err
 	}
 
-	for _, context := range patch.Contexts {
-		applyContextChanges(config, context)
+	after, err := clientcmd.Write(*config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
 	}
 
-	if purge {
-		purgeKubeConfig(patch, config)
+	// Nothing changed, so a backup would only push a useful one out of the retention window
+	if bytes.Equal(before, after) {
+		return nil
 	}
+
+	if backup {
+		if _, err = backupKubeConfig(filename, backupRetention); err != nil {
+			return err
+		}
+	}
+
+	if err = writeFileAtomic(config, filename); err != nil {
+		return fmt.Errorf("failed to write kubeconfig to file: %w", err)
+	}
+
+	return nil
+}
+
+type ApplyOptions struct {
+	// Remove entries that are not part of the patch.
+	Purge bool
+	// How to handle generated entries whose names collide with entries gogok8s did not create, defaults to
+	// ConflictSkip.
+	OnConflict ConflictPolicy
+}
+
+// ApplyPatch applies the patch to the kubeconfig, returning a diff of every change that was made along with any
+// conflicts that were found. The patch itself is left untouched, and a later Purge using it keeps the entries that were
+// renamed to resolve a conflict.
+func ApplyPatch(patch *KubeConfigPatch, config *api.Config, opts ApplyOptions) *Diff {
+	diff := &Diff{}
+
+	if patch == nil {
+		return diff
+	}
+
+	policy := opts.OnConflict
+	if policy == "" {
+		policy = ConflictSkip
+	}
+
+	resolution := newConflictResolution()
+	applyClusters(config, patch, policy, resolution, diff)
+	applyUsers(config, patch, policy, resolution, diff)
+	applyContexts(config, patch, policy, resolution, diff)
+
+	if opts.Purge {
+		diff.Merge(Purge(patch, config))
+	}
+
+	diff.sort()
+
+	return diff
+}
+
+// Purge removes clusters, users and contexts that are not part of the patch from the kubeconfig, returning a diff of
+// the removed entries.
+func Purge(patch *KubeConfigPatch, config *api.Config) *Diff {
+	diff := purgeKubeConfig(patch, config)
+	diff.sort()
+
+	return diff
 }
 
 func getKubeConfigFilePath() (string, error) {
@@ -0,0 +1,175 @@
+package kubecfg_test
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func newTestPatch(name, server, profile string) *kubecfg.KubeConfigPatch {
+	metadata := kubecfg.NewMetadataExtensions(kubecfg.Metadata{Account: "Dev", Region: "us-east-1", ClusterName: name})
+
+	return &kubecfg.KubeConfigPatch{
+		Clusters: []*v1.NamedCluster{
+			{Name: name, Cluster: v1.Cluster{
+				Server:                   server,
+				CertificateAuthorityData: []byte("ca-data"),
+				Extensions:               metadata,
+			}},
+		},
+		Users: []*v1.NamedAuthInfo{
+			{Name: name, AuthInfo: v1.AuthInfo{Exec: &v1.ExecConfig{
+				Command:    "aws-iam-authenticator",
+				Args:       []string{"token", "-i", name},
+				Env:        []v1.ExecEnvVar{{Name: "AWS_PROFILE", Value: profile}},
+				APIVersion: "client.authentication.k8s.io/v1beta1",
+			}, Extensions: metadata}},
+		},
+		Contexts: []*v1.NamedContext{
+			{Name: name, Context: v1.Context{Cluster: name, AuthInfo: name, Extensions: metadata}},
+		},
+	}
+}
+
+func findChange(t *testing.T, changes []kubecfg.Change, name string) kubecfg.Change {
+	t.Helper()
+
+	for _, change := range changes {
+		if change.Name == name {
+			return change
+		}
+	}
+
+	t.Fatalf("no change found for %s in %v", name, changes)
+
+	return kubecfg.Change{}
+}
+
+func TestApplyPatchDiff(t *testing.T) {
+	t.Parallel()
+
+	config := api.NewConfig()
+
+	diff := kubecfg.ApplyPatch(newTestPatch("foo", "https://localhost:7777", "dev"), config, kubecfg.ApplyOptions{})
+	for _, changes := range [][]kubecfg.Change{diff.Clusters, diff.Users, diff.Contexts} {
+		if change := findChange(t, changes, "foo"); change.Type != kubecfg.ChangeAdded {
+			t.Errorf("expected foo to be added, but it was %s", change.Type)
+		}
+	}
+
+	// Re-applying the same patch is a no-op
+	if diff = kubecfg.ApplyPatch(newTestPatch("foo", "https://localhost:7777", "dev"), config, kubecfg.ApplyOptions{}); !diff.Empty() {
+		t.Errorf("expected an empty diff when re-applying a patch, got %+v", diff)
+	}
+
+	config.Clusters["stale"] = &api.Cluster{Server: "https://localhost:9999"}
+	config.AuthInfos["stale"] = &api.AuthInfo{Token: "token"}
+	config.Contexts["stale"] = &api.Context{Cluster: "stale", AuthInfo: "stale"}
+	config.Clusters["minikube"] = &api.Cluster{Server: "https://localhost:8443"}
+
+	diff = kubecfg.ApplyPatch(newTestPatch("foo", "https://localhost:8888", "prod"), config, kubecfg.ApplyOptions{Purge: true})
+
+	cluster := findChange(t, diff.Clusters, "foo")
+	if cluster.Type != kubecfg.ChangeModified || len(cluster.Fields) != 1 || cluster.Fields[0].Field != "server" {
+		t.Errorf("expected only the server of cluster foo to be modified, got %+v", cluster)
+	}
+
+	user := findChange(t, diff.Users, "foo")
+	if len(user.Fields) != 1 || user.Fields[0].Old != "AWS_PROFILE=dev" || user.Fields[0].New != "AWS_PROFILE=prod" {
+		t.Errorf("expected only the exec env of user foo to be modified, got %+v", user)
+	}
+
+	for _, changes := range [][]kubecfg.Change{diff.Clusters, diff.Users, diff.Contexts} {
+		if change := findChange(t, changes, "stale"); change.Type != kubecfg.ChangeRemoved {
+			t.Errorf("expected stale to be removed, but it was %s", change.Type)
+		}
+	}
+
+	if _, ok := config.Clusters["minikube"]; !ok {
+		t.Error("purge removed the ignored minikube cluster")
+	}
+}
+
+func TestApplyPatchRenameLeavesPatchUntouched(t *testing.T) {
+	t.Parallel()
+
+	config := api.NewConfig()
+	config.Clusters["foo"] = &api.Cluster{Server: "https://localhost:9999"}
+	config.AuthInfos["foo"] = &api.AuthInfo{Token: "token"}
+
+	patch := newTestPatch("foo", "https://localhost:7777", "dev")
+	opts := kubecfg.ApplyOptions{OnConflict: kubecfg.ConflictRename}
+
+	if diff := kubecfg.ApplyPatch(patch, config, opts); len(diff.Conflicts) != 2 {
+		t.Fatalf("expected the cluster and user to be renamed, got %+v", diff.Conflicts)
+	}
+
+	if patch.Clusters[0].Name != "foo" || patch.Users[0].Name != "foo" || patch.Contexts[0].Name != "foo" ||
+		patch.Contexts[0].Context.Cluster != "foo" || patch.Contexts[0].Context.AuthInfo != "foo" {
+		t.Fatalf("expected the patch to be left untouched, got %+v", patch.Contexts[0])
+	}
+
+	// Applying the same patch again finds the same conflicts and keeps writing to the renamed entries
+	if diff := kubecfg.ApplyPatch(patch, config, opts); len(diff.Conflicts) != 2 || !diff.Empty() {
+		t.Errorf("expected the same conflicts without changes, got %+v", diff)
+	}
+
+	if purged := kubecfg.Purge(patch, config); !purged.Empty() {
+		t.Errorf("expected the renamed entries to be kept by purge, got %+v", purged)
+	}
+}
+
+func TestApplyPatchConflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		policy      kubecfg.ConflictPolicy
+		userName    string
+		contextUser string
+		conflicts   int
+	}{
+		// The conflicting user and the context that references it are both skipped
+		{policy: kubecfg.ConflictSkip, userName: "foo", contextUser: "", conflicts: 2},
+		{policy: kubecfg.ConflictOverwrite, userName: "foo", contextUser: "foo", conflicts: 1},
+		{policy: kubecfg.ConflictRename, userName: "foo.gogok8s", contextUser: "foo.gogok8s", conflicts: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			t.Parallel()
+
+			config := api.NewConfig()
+			config.AuthInfos["foo"] = &api.AuthInfo{Token: "token"}
+
+			diff := kubecfg.ApplyPatch(newTestPatch("foo", "https://localhost:7777", "dev"), config, kubecfg.ApplyOptions{
+				OnConflict: test.policy,
+			})
+
+			if len(diff.Conflicts) != test.conflicts {
+				t.Fatalf("expected %d conflicts, got %+v", test.conflicts, diff.Conflicts)
+			}
+
+			if user := config.AuthInfos[test.userName]; user.Exec == nil && test.policy != kubecfg.ConflictSkip {
+				t.Errorf("user %s was not written by gogok8s", test.userName)
+			}
+
+			if test.policy == kubecfg.ConflictOverwrite && config.AuthInfos["foo"].Token != "" {
+				t.Error("overwritten user kept its token")
+			}
+
+			if test.policy == kubecfg.ConflictRename && config.AuthInfos["foo"].Token != "token" {
+				t.Error("renaming modified the existing user")
+			}
+
+			context, ok := config.Contexts["foo"]
+			if test.contextUser == "" && ok {
+				t.Error("context referencing a skipped user was written")
+			} else if test.contextUser != "" && context.AuthInfo != test.contextUser {
+				t.Errorf("context foo references user %s, expected %s", context.AuthInfo, test.contextUser)
+			}
+		})
+	}
+}
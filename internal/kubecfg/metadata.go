@@ -0,0 +1,82 @@
+package kubecfg
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	v1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+const metadataExtensionName = "gogok8s"
+
+// Metadata records the account, region and cluster a kubeconfig entry was generated from. It is stored as a
+// kubeconfig extension on every cluster, user and context gogok8s writes, which is how gogok8s tells the entries it
+// manages apart from ones created by other tools.
+type Metadata struct {
+	Account     string `json:"account" yaml:"account"`
+	Region      string `json:"region,omitempty" yaml:"region,omitempty"`
+	ClusterName string `json:"clusterName,omitempty" yaml:"clusterName,omitempty"`
+	ClusterArn  string `json:"clusterArn,omitempty" yaml:"clusterArn,omitempty"`
+	ExtraUser   string `json:"extraUser,omitempty" yaml:"extraUser,omitempty"`
+}
+
+// NewMetadataExtensions returns the kubeconfig extensions used to attach metadata to an entry of a KubeConfigPatch.
+func NewMetadataExtensions(metadata Metadata) []v1.NamedExtension {
+	raw, _ := json.Marshal(metadata)
+
+	return []v1.NamedExtension{
+		{
+			Name:      metadataExtensionName,
+			Extension: runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+// GetMetadata returns the gogok8s metadata stored within the extensions of a kubeconfig entry. False is returned when
+// the entry was not created by gogok8s.
+func GetMetadata(extensions map[string]runtime.Object) (Metadata, bool) {
+	var metadata Metadata
+
+	extension, ok := extensions[metadataExtensionName]
+	if !ok {
+		return metadata, false
+	}
+
+	unknown, ok := extension.(*runtime.Unknown)
+	if !ok {
+		return metadata, false
+	}
+
+	if err := json.Unmarshal(unknown.Raw, &metadata); err != nil {
+		return metadata, false
+	}
+
+	return metadata, true
+}
+
+func isManaged(extensions map[string]runtime.Object) bool {
+	_, ok := GetMetadata(extensions)
+
+	return ok
+}
+
+// mergeMetadataExtensions copies the gogok8s extension of a patch entry into the extensions of a kubeconfig entry,
+// leaving any other extensions untouched.
+func mergeMetadataExtensions(extensions map[string]runtime.Object, patchExtensions []v1.NamedExtension) map[string]runtime.Object {
+	for _, extension := range patchExtensions {
+		if extension.Name != metadataExtensionName {
+			continue
+		}
+
+		if extensions == nil {
+			extensions = make(map[string]runtime.Object)
+		}
+
+		extensions[metadataExtensionName] = &runtime.Unknown{
+			Raw:         extension.Extension.Raw,
+			ContentType: runtime.ContentTypeJSON,
+		}
+	}
+
+	return extensions
+}
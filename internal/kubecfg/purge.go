@@ -1,7 +1,10 @@
 package kubecfg
 
 import (
-	"github.com/BigPapaChas/gogok8s/internal/terminal"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
@@ -29,13 +32,15 @@ var defaultIgnoredContexts = map[string]struct{}{
 	"microk8s":       {},
 }
 
-func purgeKubeConfig(patch *KubeConfigPatch, config *api.Config) {
-	purgeClusters(patch, config)
-	purgeUsers(patch, config)
-	purgeContexts(patch, config)
+func purgeKubeConfig(patch *KubeConfigPatch, config *api.Config) *Diff {
+	return &Diff{
+		Clusters: purgeClusters(patch, config),
+		Users:    purgeUsers(patch, config),
+		Contexts: purgeContexts(patch, config),
+	}
 }
 
-func purgeClusters(patch *KubeConfigPatch, config *api.Config) {
+func purgeClusters(patch *KubeConfigPatch, config *api.Config) []Change {
 	existingClusters := make(map[string]struct{})
 	for _, cluster := range patch.Clusters {
 		existingClusters[cluster.Name] = struct{}{}
@@ -49,18 +54,22 @@ func purgeClusters(patch *KubeConfigPatch, config *api.Config) {
 			continue
 		}
 
-		if _, ok := existingClusters[name]; !ok {
-			terminal.DiffMinus(name)
+		if _, ok := existingClusters[name]; !ok && !isRenamedEntry(name, config.Clusters[name].Extensions, existingClusters) {
 			clustersToDelete = append(clustersToDelete, name)
 		}
 	}
 
+	var changes []Change
+
 	for _, cluster := range clustersToDelete {
+		changes = append(changes, Change{Name: cluster, Type: ChangeRemoved})
 		delete(config.Clusters, cluster)
 	}
+
+	return changes
 }
 
-func purgeUsers(patch *KubeConfigPatch, config *api.Config) {
+func purgeUsers(patch *KubeConfigPatch, config *api.Config) []Change {
 	existingUsers := make(map[string]struct{})
 	for _, user := range patch.Users {
 		existingUsers[user.Name] = struct{}{}
@@ -74,17 +83,22 @@ func purgeUsers(patch *KubeConfigPatch, config *api.Config) {
 			continue
 		}
 
-		if _, ok := existingUsers[name]; !ok {
+		if _, ok := existingUsers[name]; !ok && !isRenamedEntry(name, config.AuthInfos[name].Extensions, existingUsers) {
 			usersToDelete = append(usersToDelete, name)
 		}
 	}
 
+	var changes []Change
+
 	for _, user := range usersToDelete {
+		changes = append(changes, Change{Name: user, Type: ChangeRemoved})
 		delete(config.AuthInfos, user)
 	}
+
+	return changes
 }
 
-func purgeContexts(patch *KubeConfigPatch, config *api.Config) {
+func purgeContexts(patch *KubeConfigPatch, config *api.Config) []Change {
 	existingContexts := make(map[string]struct{})
 	for _, context := range patch.Contexts {
 		existingContexts[context.Name] = struct{}{}
@@ -98,12 +112,43 @@ func purgeContexts(patch *KubeConfigPatch, config *api.Config) {
 			continue
 		}
 
-		if _, ok := existingContexts[name]; !ok {
+		if _, ok := existingContexts[name]; !ok && !isRenamedEntry(name, config.Contexts[name].Extensions, existingContexts) {
 			contextsToDelete = append(contextsToDelete, name)
 		}
 	}
 
+	var changes []Change
+
 	for _, context := range contextsToDelete {
+		changes = append(changes, Change{Name: context, Type: ChangeRemoved})
 		delete(config.Contexts, context)
 	}
+
+	return changes
+}
+
+// isRenamedEntry returns whether name is a managed entry that an entry of the patch was written as to resolve a
+// conflict, e.g. foo.gogok8s or foo.gogok8s-2 for foo.
+func isRenamedEntry(name string, extensions map[string]runtime.Object, patchNames map[string]struct{}) bool {
+	if !isManaged(extensions) {
+		return false
+	}
+
+	base, ok := strings.CutSuffix(name, renameSuffix)
+	if !ok {
+		idx := strings.LastIndex(name, renameSuffix+"-")
+		if idx < 0 {
+			return false
+		}
+
+		if _, err := strconv.Atoi(name[idx+len(renameSuffix)+1:]); err != nil {
+			return false
+		}
+
+		base = name[:idx]
+	}
+
+	_, ok = patchNames[base]
+
+	return ok
 }
@@ -0,0 +1,111 @@
+package kubecfg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	lockTimeout      = 10 * time.Second
+	lockPollInterval = 100 * time.Millisecond
+
+	kubeconfigFilemode    = os.FileMode(0o600)
+	kubeconfigDirFilemode = os.FileMode(0o755)
+)
+
+var ErrLockTimeout = errors.New("timed out waiting for kubeconfig lock")
+
+// lockFile takes an advisory lock on filename by exclusively creating filename.lock, the same convention client-go
+// (and therefore kubectl) uses when modifying a kubeconfig. The lock is retried until lockTimeout has passed, so that
+// concurrent writers queue up behind each other.
+func lockFile(filename string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(filename), kubeconfigDirFilemode); err != nil {
+		return nil, fmt.Errorf("failed to create kubeconfig directory: %w", err)
+	}
+
+	lockName := filename + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockName, os.O_CREATE|os.O_EXCL, 0)
+		if err == nil {
+			_ = f.Close()
+
+			return func() { _ = os.Remove(lockName) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock kubeconfig: %w", err)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: remove %s if no other process is modifying the kubeconfig", ErrLockTimeout, lockName)
+		}
+
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// writeFileAtomic writes the kubeconfig to a temporary file within the same directory, syncs it to disk and then
+// renames it over filename so that readers never observe a partially written kubeconfig. The permissions of an existing
+// file are kept.
+func writeFileAtomic(config *api.Config, filename string) error {
+	content, err := clientcmd.Write(*config)
+	if err != nil {
+		return fmt.Errorf("failed to serialize kubeconfig: %w", err)
+	}
+
+	mode := kubeconfigFilemode
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary kubeconfig: %w", err)
+	}
+
+	// Clean up the temporary file if anything fails before it has been renamed
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err = tmp.Write(content); err != nil {
+		return fmt.Errorf("failed to write temporary kubeconfig: %w", err)
+	}
+
+	if err = tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set kubeconfig permissions: %w", err)
+	}
+
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary kubeconfig: %w", err)
+	}
+
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary kubeconfig: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to replace kubeconfig: %w", err)
+	}
+
+	return nil
+}
+
+// resolveKubeConfigPath follows symlinks so that a symlinked kubeconfig is updated in place rather than replaced.
+func resolveKubeConfigPath(filename string) string {
+	resolved, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return filename
+	}
+
+	return resolved
+}
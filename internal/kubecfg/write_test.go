@@ -0,0 +1,183 @@
+package kubecfg_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+)
+
+func TestUpdateKeepsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on windows")
+	}
+
+	home := t.TempDir()
+	kubeconfig := filepath.Join(home, "config")
+
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	if err := os.WriteFile(kubeconfig, []byte(testKubeConfig), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	err := kubecfg.Update(1, func(config *api.Config) error {
+		config.Clusters["bar"] = &api.Cluster{Server: "https://localhost:8888"}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("kubeconfig mode is %o, but expected %o", info.Mode().Perm(), 0o640)
+	}
+
+	config, err := kubecfg.LoadDefault()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := config.Clusters["bar"]; !ok {
+		t.Error("kubeconfig is missing cluster bar after update")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(home, "config.*"))
+	if len(matches) > 0 {
+		t.Errorf("temporary or lock files were left behind: %v", matches)
+	}
+}
+
+func TestUpdateSkipsUnchanged(t *testing.T) {
+	home := t.TempDir()
+	kubeconfig := filepath.Join(home, "config")
+
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	if err := os.WriteFile(kubeconfig, []byte(testKubeConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := kubecfg.Update(1, func(config *api.Config) error {
+		config.Clusters["foo"].Server = "https://localhost:7777"
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if backups, _ := kubecfg.ListBackups(); len(backups) != 0 {
+		t.Errorf("expected no backup of an unchanged kubeconfig, got %d", len(backups))
+	}
+
+	if content, _ := os.ReadFile(kubeconfig); string(content) != testKubeConfig {
+		t.Errorf("expected an unchanged kubeconfig to be left as is, got:\n%s", content)
+	}
+}
+
+func TestWriteWaitsForLock(t *testing.T) {
+	home := t.TempDir()
+	kubeconfig := filepath.Join(home, "config")
+	lock := kubeconfig + ".lock"
+
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	if err := os.WriteFile(lock, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	released := make(chan struct{})
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_ = os.Remove(lock)
+
+		close(released)
+	}()
+
+	config := api.NewConfig()
+	config.Clusters["foo"] = &api.Cluster{Server: "https://localhost:7777"}
+
+	if err := kubecfg.Write(config); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-released:
+	default:
+		t.Fatal("Write() did not wait for the existing lock to be released")
+	}
+
+	if _, err := os.Stat(lock); !os.IsNotExist(err) {
+		t.Error("Write() did not release its lock")
+	}
+}
+
+func TestUpdateLocksSymlinkedKubeConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks are not supported on windows")
+	}
+
+	home := t.TempDir()
+	target := filepath.Join(home, "dotfiles", "kubeconfig")
+	kubeconfig := filepath.Join(home, "config")
+
+	t.Setenv("HOME", home)
+	t.Setenv("KUBECONFIG", kubeconfig)
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(target, []byte(testKubeConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink(target, kubeconfig); err != nil {
+		t.Fatal(err)
+	}
+
+	err := kubecfg.Update(1, func(config *api.Config) error {
+		// kubectl locks the configured path, not the file the symlink points at
+		if _, err := os.Stat(kubeconfig + ".lock"); err != nil {
+			t.Errorf("expected the configured path to be locked: %v", err)
+		}
+
+		config.Clusters["bar"] = &api.Cluster{Server: "https://localhost:8888"}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(kubeconfig); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected the kubeconfig to still be a symlink, got %v", err)
+	}
+
+	config, err := kubecfg.LoadFromFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := config.Clusters["bar"]; !ok {
+		t.Error("expected the symlink target to be updated")
+	}
+}
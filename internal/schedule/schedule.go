@@ -0,0 +1,264 @@
+package schedule
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	// UnitName is the name shared by the service and timer units, so the timer activates the service.
+	UnitName = "gogok8s-sync"
+
+	unitDirFilemode  = os.FileMode(0o755)
+	unitFileFilemode = os.FileMode(0o644)
+)
+
+var ErrEmptySchedule = errors.New("a calendar schedule is required")
+
+//nolint:gochecknoglobals
+var serviceTemplate = template.Must(template.New("service").Parse(`[Unit]
+Description=Sync kubeconfig with EKS clusters using gogok8s
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=oneshot
+ExecStart={{ .ExecStart }}
+{{- range .Environment }}
+Environment={{ . }}
+{{- end }}
+`))
+
+//nolint:gochecknoglobals
+var timerTemplate = template.Must(template.New("timer").Parse(`[Unit]
+Description=Run gogok8s sync on a schedule
+
+[Timer]
+OnCalendar={{ .OnCalendar }}
+Persistent=true
+RandomizedDelaySec=60
+
+[Install]
+WantedBy=timers.target
+`))
+
+// Options describes the command the service runs and when the timer runs it.
+type Options struct {
+	// Executable is the absolute path of the gogok8s binary.
+	Executable string
+	// Args are the arguments passed to gogok8s, e.g. sync and its accounts and flags.
+	Args []string
+	// OnCalendar is a systemd calendar expression, such as hourly or *-*-* 09:00.
+	OnCalendar string
+	// Environment is set on the service, e.g. KUBECONFIG so the timer writes the same kubeconfig as the shell.
+	Environment map[string]string
+}
+
+// Units holds the rendered contents of the service and timer units.
+type Units struct {
+	Service string
+	Timer   string
+}
+
+// Status describes the installed units.
+type Status struct {
+	Installed   bool
+	ServicePath string
+	TimerPath   string
+	ExecStart   string
+	OnCalendar  string
+}
+
+// Render returns the service and timer units for the options.
+func Render(opts Options) (Units, error) {
+	if opts.OnCalendar == "" {
+		return Units{}, ErrEmptySchedule
+	}
+
+	args := make([]string, 0, len(opts.Args)+1)
+	for _, arg := range append([]string{opts.Executable}, opts.Args...) {
+		args = append(args, quote(arg, true))
+	}
+
+	keys := make([]string, 0, len(opts.Environment))
+	for key := range opts.Environment {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	environment := make([]string, 0, len(keys))
+	for _, key := range keys {
+		environment = append(environment, quote(key+"="+opts.Environment[key], false))
+	}
+
+	var service, timer bytes.Buffer
+
+	err := serviceTemplate.Execute(&service, map[string]any{
+		"ExecStart":   strings.Join(args, " "),
+		"Environment": environment,
+	})
+	if err != nil {
+		return Units{}, fmt.Errorf("failed to render service unit: %w", err)
+	}
+
+	if err = timerTemplate.Execute(&timer, map[string]any{"OnCalendar": opts.OnCalendar}); err != nil {
+		return Units{}, fmt.Errorf("failed to render timer unit: %w", err)
+	}
+
+	return Units{Service: service.String(), Timer: timer.String()}, nil
+}
+
+// Install writes the units into dir, returning the paths of the written files.
+func Install(dir string, opts Options) ([]string, error) {
+	units, err := Render(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(dir, unitDirFilemode); err != nil {
+		return nil, fmt.Errorf("failed to create systemd unit directory: %w", err)
+	}
+
+	servicePath, timerPath := unitPaths(dir)
+
+	if err = os.WriteFile(servicePath, []byte(units.Service), unitFileFilemode); err != nil {
+		return nil, fmt.Errorf("failed to write service unit: %w", err)
+	}
+
+	if err = os.WriteFile(timerPath, []byte(units.Timer), unitFileFilemode); err != nil {
+		return nil, fmt.Errorf("failed to write timer unit: %w", err)
+	}
+
+	return []string{servicePath, timerPath}, nil
+}
+
+// Remove deletes the units from dir, returning the paths of the removed files.
+func Remove(dir string) ([]string, error) {
+	var removed []string
+
+	servicePath, timerPath := unitPaths(dir)
+
+	for _, path := range []string{timerPath, servicePath} {
+		err := os.Remove(path)
+		if err != nil && errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+
+		removed = append(removed, path)
+	}
+
+	return removed, nil
+}
+
+// GetStatus reads the installed units from dir.
+func GetStatus(dir string) (Status, error) {
+	servicePath, timerPath := unitPaths(dir)
+	status := Status{ServicePath: servicePath, TimerPath: timerPath}
+
+	service, err := os.ReadFile(servicePath)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	} else if err != nil {
+		return status, fmt.Errorf("failed to read service unit: %w", err)
+	}
+
+	timer, err := os.ReadFile(timerPath)
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return status, nil
+	} else if err != nil {
+		return status, fmt.Errorf("failed to read timer unit: %w", err)
+	}
+
+	status.Installed = true
+	status.ExecStart = unitValue(string(service), "ExecStart")
+	status.OnCalendar = unitValue(string(timer), "OnCalendar")
+
+	return status, nil
+}
+
+// EnableCommands returns the systemctl commands that start the timer after it has been installed.
+func EnableCommands() [][]string {
+	return [][]string{
+		{"systemctl", "--user", "daemon-reload"},
+		{"systemctl", "--user", "enable", "--now", UnitName + ".timer"},
+	}
+}
+
+// DisableCommands returns the systemctl commands that stop the timer before it is removed.
+func DisableCommands() [][]string {
+	return [][]string{
+		{"systemctl", "--user", "disable", "--now", UnitName + ".timer"},
+	}
+}
+
+// Run runs systemctl commands in order, stopping at the first failure.
+func Run(commands [][]string) error {
+	for _, command := range commands {
+		//nolint:gosec
+		cmd := exec.Command(command[0], command[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run `%s`: %w", strings.Join(command, " "), err)
+		}
+	}
+
+	return nil
+}
+
+// UnitDir returns the directory systemd reads user units from.
+func UnitDir() (string, error) {
+	if dir, ok := os.LookupEnv("XDG_CONFIG_HOME"); ok && dir != "" {
+		return filepath.Join(dir, "systemd", "user"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find user home directory: %w", err)
+	}
+
+	return filepath.Join(home, ".config", "systemd", "user"), nil
+}
+
+func unitPaths(dir string) (string, string) {
+	return filepath.Join(dir, UnitName+".service"), filepath.Join(dir, UnitName+".timer")
+}
+
+func unitValue(unit, key string) string {
+	for _, line := range strings.Split(unit, "\n") {
+		if value, ok := strings.CutPrefix(line, key+"="); ok {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// quote escapes a value for a systemd unit. Specifiers (%) are always escaped and variables ($) are escaped for ExecStart,
+// the only setting that expands them. Values containing whitespace, quotes or backslashes are wrapped in double quotes.
+func quote(arg string, exec bool) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	if exec {
+		arg = strings.ReplaceAll(arg, "$", "$$")
+	}
+
+	if arg != "" && !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	arg = strings.ReplaceAll(arg, `"`, `\"`)
+
+	return `"` + arg + `"`
+}
@@ -0,0 +1,114 @@
+package schedule_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/schedule"
+)
+
+//nolint:gochecknoglobals
+var update = flag.Bool("update", false, "updates the golden files")
+
+func assertGolden(t *testing.T, name, actual string) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.WriteFile(golden, []byte(actual), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(expected) != actual {
+		t.Errorf("%s does not match the golden file:\n%s", name, actual)
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts schedule.Options
+	}{
+		{
+			name: "default",
+			opts: schedule.Options{
+				Executable: "/usr/local/bin/gogok8s",
+				Args:       []string{"sync"},
+				OnCalendar: "hourly",
+			},
+		},
+		{
+			name: "accounts",
+			opts: schedule.Options{
+				Executable: "/home/dev/my tools/gogok8s",
+				Args: []string{
+					"sync", "Dev", "Staging", "--purge", "--on-conflict", "rename", "--config", "/home/dev/.gogok8s.yaml",
+				},
+				OnCalendar:  "Mon..Fri *-*-* 08:30",
+				Environment: map[string]string{"KUBECONFIG": "/home/dev/.kube/config", "AWS_CONFIG_FILE": "/home/dev/aws 100%"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			units, err := schedule.Render(test.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertGolden(t, test.name+".service", units.Service)
+			assertGolden(t, test.name+".timer", units.Timer)
+		})
+	}
+}
+
+func TestInstallAndRemove(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "systemd", "user")
+
+	if _, err := schedule.Install(dir, schedule.Options{Executable: "gogok8s"}); !errors.Is(err, schedule.ErrEmptySchedule) {
+		t.Fatalf("expected ErrEmptySchedule, got %v", err)
+	}
+
+	paths, err := schedule.Install(dir, schedule.Options{Executable: "gogok8s", Args: []string{"sync"}, OnCalendar: "daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 unit files, got %v", paths)
+	}
+
+	status, err := schedule.GetStatus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !status.Installed || status.ExecStart != "gogok8s sync" || status.OnCalendar != "daily" {
+		t.Errorf("unexpected status %+v", status)
+	}
+
+	if paths, err = schedule.Remove(dir); err != nil || len(paths) != 2 {
+		t.Fatalf("expected 2 removed unit files, got %v: %v", paths, err)
+	}
+
+	if status, _ = schedule.GetStatus(dir); status.Installed {
+		t.Error("expected the units to be removed")
+	}
+}
@@ -0,0 +1,67 @@
+package source
+
+import "strings"
+
+type LineKind int
+
+const (
+	LineUnchanged LineKind = iota
+	LineAdded
+	LineRemoved
+)
+
+type Line struct {
+	Kind LineKind
+	Text string
+}
+
+// Diff compares two versions of a fragment line by line, returning every line of both in order with removed lines
+// before the lines that replace them.
+func Diff(before, after []byte) []Line {
+	a, b := splitLines(before), splitLines(after)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]Line, 0, max(len(a), len(b)))
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, Line{Kind: LineUnchanged, Text: a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, Line{Kind: LineRemoved, Text: a[i]})
+			i++
+		default:
+			lines = append(lines, Line{Kind: LineAdded, Text: b[j]})
+			j++
+		}
+	}
+
+	return lines
+}
+
+func splitLines(data []byte) []string {
+	text := strings.TrimSuffix(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(text, "\n")
+}
@@ -0,0 +1,204 @@
+package source
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	cacheDirName      = "gogok8s"
+	cacheSubdirName   = "sources"
+	cacheDirFilemode  = os.FileMode(0o700)
+	cacheFileFilemode = os.FileMode(0o600)
+
+	// maxFragmentSize bounds how much of a response is read, a config fragment is a few kilobytes at most.
+	maxFragmentSize = 1 << 20
+
+	gitPathSeparator = "//"
+	gitRefParameter  = "?ref="
+	defaultGitRef    = "HEAD"
+)
+
+var (
+	ErrInvalidLocation  = errors.New("invalid source location, expected an https URL or <git-repo>//<path>")
+	ErrInsecureLocation = errors.New("sources can't be fetched over plain http, use https")
+	ErrInvalidRef       = errors.New("invalid git ref, refs can't start with '-'")
+	ErrFetchFailed      = errors.New("failed to fetch source")
+	ErrFragmentTooLarge = errors.New("source is larger than 1MiB")
+	ErrNotCached        = errors.New("source hasn't been pulled")
+)
+
+// Location is where a config fragment is fetched from, either an https URL or a file within a local git checkout.
+type Location struct {
+	// The https URL of the fragment, empty for git locations.
+	URL string
+	// The directory of the git checkout, the path of the fragment within it and the ref to read it at.
+	Repo string
+	Path string
+	Ref  string
+}
+
+// ParseLocation parses an https URL, or a file within a local git checkout written as <repo>//<path>, optionally
+// followed by ?ref=<ref> to read the file at a branch, tag or commit rather than HEAD. Git locations only ever read
+// committed contents, so uncommitted changes to a checkout can't slip past review.
+func ParseLocation(location string) (Location, error) {
+	switch {
+	case strings.HasPrefix(location, "https://"):
+		return Location{URL: location}, nil
+	case strings.HasPrefix(location, "http://"):
+		return Location{}, fmt.Errorf("%w: %s", ErrInsecureLocation, location)
+	case strings.Contains(location, gitPathSeparator):
+		rest, ref, _ := strings.Cut(location, gitRefParameter)
+		repo, path, _ := strings.Cut(rest, gitPathSeparator)
+
+		if repo == "" || path == "" {
+			return Location{}, fmt.Errorf("%w: %s", ErrInvalidLocation, location)
+		}
+
+		// The ref is passed to git, where a leading dash would be read as an option
+		if strings.HasPrefix(ref, "-") {
+			return Location{}, fmt.Errorf("%w: %s", ErrInvalidRef, location)
+		}
+
+		if ref == "" {
+			ref = defaultGitRef
+		}
+
+		return Location{Repo: repo, Path: strings.TrimPrefix(path, "/"), Ref: ref}, nil
+	default:
+		return Location{}, fmt.Errorf("%w: %s", ErrInvalidLocation, location)
+	}
+}
+
+// Fetch returns the contents of the fragment at location, using client for https locations.
+func Fetch(ctx context.Context, client *http.Client, location string) ([]byte, error) {
+	parsed, err := ParseLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if parsed.URL != "" {
+		return fetchHTTP(ctx, client, parsed.URL)
+	}
+
+	return fetchGit(ctx, parsed)
+}
+
+func fetchHTTP(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrFetchFailed, url, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrFetchFailed, url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w %s: %s", ErrFetchFailed, url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxFragmentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", ErrFetchFailed, url, err)
+	}
+
+	if len(data) > maxFragmentSize {
+		return nil, fmt.Errorf("%w: %s", ErrFragmentTooLarge, url)
+	}
+
+	return data, nil
+}
+
+func fetchGit(ctx context.Context, location Location) ([]byte, error) {
+	repo := location.Repo
+	if rest, ok := strings.CutPrefix(repo, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user home directory: %w", err)
+		}
+
+		repo = filepath.Join(home, rest)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, "git", "-C", repo, "show", location.Ref+":"+location.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%w %s at %s in %s: %s", ErrFetchFailed, location.Path, location.Ref, repo,
+			strings.TrimSpace(stderr.String()))
+	}
+
+	if stdout.Len() > maxFragmentSize {
+		return nil, fmt.Errorf("%w: %s", ErrFragmentTooLarge, location.Path)
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// Checksum returns the hex encoded SHA-256 of data, which is what a source is pinned by.
+func Checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Cache stores the last accepted contents of every source, so that the config can be read without fetching them.
+type Cache struct {
+	Dir string
+}
+
+// DefaultCache returns the cache within the user cache directory.
+func DefaultCache() (*Cache, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user cache directory: %w", err)
+	}
+
+	return &Cache{Dir: filepath.Join(dir, cacheDirName, cacheSubdirName)}, nil
+}
+
+// Path returns the file the contents of location are cached in.
+func (c *Cache) Path(location string) string {
+	return filepath.Join(c.Dir, Checksum([]byte(location))[:16]+".yaml")
+}
+
+// Read returns the cached contents of location, or ErrNotCached when it hasn't been pulled yet.
+func (c *Cache) Read(location string) ([]byte, error) {
+	data, err := os.ReadFile(c.Path(location))
+	if err != nil && errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotCached, location)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read cached source: %w", err)
+	}
+
+	return data, nil
+}
+
+func (c *Cache) Write(location string, data []byte) error {
+	if err := os.MkdirAll(c.Dir, cacheDirFilemode); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err := os.WriteFile(c.Path(location), data, cacheFileFilemode); err != nil {
+		return fmt.Errorf("failed to write cached source: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package source_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/BigPapaChas/gogok8s/internal/source"
+)
+
+const fragment = `apiVersion: gogok8s/v1
+accounts:
+  - name: Prod
+    profile: prod
+    regions: [us-east-1]
+`
+
+func TestFetchHTTPS(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/team.yaml" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(fragment))
+	}))
+	defer server.Close()
+
+	data, err := source.Fetch(context.Background(), server.Client(), server.URL+"/team.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != fragment {
+		t.Fatalf("expected the served fragment, got %q", data)
+	}
+
+	_, err = source.Fetch(context.Background(), server.Client(), server.URL+"/missing.yaml")
+	if !errors.Is(err, source.ErrFetchFailed) {
+		t.Fatalf("expected ErrFetchFailed for a missing fragment, got %v", err)
+	}
+}
+
+func TestFetchRejectsPlainHTTP(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(fragment))
+	}))
+	defer server.Close()
+
+	_, err := source.Fetch(context.Background(), server.Client(), server.URL+"/team.yaml")
+	if !errors.Is(err, source.ErrInsecureLocation) {
+		t.Fatalf("expected ErrInsecureLocation, got %v", err)
+	}
+}
+
+func TestFetchGit(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=test", "-c", "user.email=test@example.com"},
+			args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	git("init", "--quiet")
+
+	if err := os.MkdirAll(filepath.Join(repo, "gogok8s"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "gogok8s", "team.yaml"), []byte(fragment), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	git("add", ".")
+	git("commit", "--quiet", "-m", "add fragment")
+
+	// Uncommitted changes aren't part of the fragment
+	if err := os.WriteFile(filepath.Join(repo, "gogok8s", "team.yaml"), []byte("accounts: []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := source.Fetch(context.Background(), nil, repo+"//gogok8s/team.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != fragment {
+		t.Fatalf("expected the committed fragment, got %q", data)
+	}
+
+	_, err = source.Fetch(context.Background(), nil, repo+"//gogok8s/team.yaml?ref=missing")
+	if !errors.Is(err, source.ErrFetchFailed) {
+		t.Fatalf("expected ErrFetchFailed for a missing ref, got %v", err)
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	t.Parallel()
+
+	location, err := source.ParseLocation("~/src/platform//gogok8s/team.yaml?ref=v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := source.Location{Repo: "~/src/platform", Path: "gogok8s/team.yaml", Ref: "v2"}
+	if location != expected {
+		t.Fatalf("expected %+v, got %+v", expected, location)
+	}
+
+	if _, err = source.ParseLocation("team.yaml"); !errors.Is(err, source.ErrInvalidLocation) {
+		t.Fatalf("expected ErrInvalidLocation, got %v", err)
+	}
+
+	if _, err = source.ParseLocation("~/platform//team.yaml?ref=--output=/tmp/x"); !errors.Is(err, source.ErrInvalidRef) {
+		t.Fatalf("expected ErrInvalidRef, got %v", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	cache := &source.Cache{Dir: filepath.Join(t.TempDir(), "sources")}
+	location := "https://example.com/team.yaml"
+
+	if _, err := cache.Read(location); !errors.Is(err, source.ErrNotCached) {
+		t.Fatalf("expected ErrNotCached, got %v", err)
+	}
+
+	if err := cache.Write(location, []byte(fragment)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := cache.Read(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if source.Checksum(data) != source.Checksum([]byte(fragment)) {
+		t.Fatalf("expected the cached fragment, got %q", data)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	lines := source.Diff([]byte("a\nb\nc\n"), []byte("a\nB\nc\nd\n"))
+
+	expected := []source.Line{
+		{Kind: source.LineUnchanged, Text: "a"},
+		{Kind: source.LineRemoved, Text: "b"},
+		{Kind: source.LineAdded, Text: "B"},
+		{Kind: source.LineUnchanged, Text: "c"},
+		{Kind: source.LineAdded, Text: "d"},
+	}
+
+	if !reflect.DeepEqual(lines, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, lines)
+	}
+}
@@ -0,0 +1,58 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidColor = errors.New("invalid color")
+
+// The ANSI escape codes of the colors that can be set within the config.
+//
+//nolint:gochecknoglobals
+var ansiColors = map[string]string{
+	"black":   "\x1b[30m",
+	"red":     "\x1b[31m",
+	"green":   "\x1b[32m",
+	"yellow":  "\x1b[33m",
+	"blue":    "\x1b[34m",
+	"magenta": "\x1b[35m",
+	"cyan":    "\x1b[36m",
+	"white":   "\x1b[37m",
+	"none":    "",
+}
+
+const ansiReset = "\x1b[0m"
+
+// ValidateColor checks that color is one of the named colors, an empty color is valid and means the default.
+func ValidateColor(color string) error {
+	if _, ok := ansiColors[color]; ok || color == "" {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s, must be one of %v", ErrInvalidColor, color, Colors())
+}
+
+// Colors returns the names of the colors that can be set within the config, sorted.
+func Colors() []string {
+	names := make([]string, 0, len(ansiColors))
+	for name := range ansiColors {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Colorize wraps text in the escape codes of color. wrap is applied to each escape code, so that shells can be told
+// the codes take up no space within the prompt.
+func Colorize(text, color string, wrap func(code string) string) string {
+	code := ansiColors[color]
+	if code == "" {
+		return text
+	}
+
+	return wrap(code) + text + wrap(ansiReset)
+}
@@ -0,0 +1,157 @@
+package terminal
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pterm/pterm"
+
+	"github.com/BigPapaChas/gogok8s/internal/kubecfg"
+	"github.com/BigPapaChas/gogok8s/internal/source"
+)
+
+func DiffAdd(message string) {
+	pterm.DefaultBasicText.Printfln("%s", pterm.LightGreen("+ ", message))
+}
+
+func DiffMinus(message string) {
+	pterm.DefaultBasicText.Printfln("%s", pterm.LightRed("- ", message))
+}
+
+func DiffModify(message string) {
+	pterm.DefaultBasicText.Printfln("%s", pterm.LightYellow("~ ", message))
+}
+
+// PrintDiff renders every change within the kubeconfig diff, grouped by clusters, users and contexts.
+func PrintDiff(diff *kubecfg.Diff) {
+	printConflicts(diff.Conflicts)
+
+	if diff.Empty() {
+		TextSuccess("No changes")
+
+		return
+	}
+
+	if diff.CurrentContext != nil {
+		DiffModify(diff.CurrentContext.Field)
+		printFieldChange(*diff.CurrentContext, "  ")
+	}
+
+	printChanges("Clusters", diff.Clusters)
+	printChanges("Users", diff.Users)
+	printChanges("Contexts", diff.Contexts)
+}
+
+func printChanges(title string, changes []kubecfg.Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	pterm.DefaultBasicText.Printfln("%s", pterm.Bold.Sprint(title))
+
+	for _, change := range changes {
+		switch change.Type {
+		case kubecfg.ChangeAdded:
+			DiffAdd(change.Name)
+		case kubecfg.ChangeRemoved:
+			DiffMinus(change.Name)
+		case kubecfg.ChangeModified:
+			DiffModify(change.Name)
+
+			for _, field := range change.Fields {
+				printFieldChange(field, "    ")
+			}
+		}
+	}
+}
+
+func printFieldChange(field kubecfg.FieldChange, indent string) {
+	if field.Old != "" {
+		pterm.DefaultBasicText.Printfln("%s%s", indent, pterm.LightRed("- ", fmt.Sprintf("%s: %s", field.Field, field.Old)))
+	}
+
+	if field.New != "" {
+		pterm.DefaultBasicText.Printfln("%s%s", indent, pterm.LightGreen("+ ", fmt.Sprintf("%s: %s", field.Field, field.New)))
+	}
+}
+
+func printConflicts(conflicts []kubecfg.Conflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	pterm.DefaultBasicText.Printfln("%s", pterm.Bold.Sprint("Conflicts"))
+
+	for _, conflict := range conflicts {
+		resolution := string(conflict.Resolution)
+		if conflict.RenamedTo != "" {
+			resolution = "renamed to " + conflict.RenamedTo
+		}
+
+		pterm.DefaultBasicText.Printfln("%s", pterm.Yellow(
+			fmt.Sprintf("! %s %s: %s (%s)", conflict.Kind, conflict.Name, conflict.Reason, resolution),
+		))
+	}
+}
+
+// PrintCollisions lists the names generated for more than one entry, along with the clusters that generated them.
+func PrintCollisions(collisions []kubecfg.Collision) {
+	if len(collisions) == 0 {
+		return
+	}
+
+	pterm.DefaultBasicText.Printfln("%s", pterm.Bold.Sprint("Collisions"))
+
+	for _, collision := range collisions {
+		pterm.DefaultBasicText.Printfln("%s", pterm.Yellow(fmt.Sprintf("! %s %s is generated for:", collision.Kind,
+			collision.Name)))
+
+		for _, source := range collision.Sources {
+			user := ""
+			if source.ExtraUser != "" {
+				user = " as " + source.ExtraUser
+			}
+
+			pterm.DefaultBasicText.Printfln("    %s/%s/%s%s (%s)", source.Account, source.Region, source.ClusterName,
+				user, source.ClusterArn)
+		}
+	}
+}
+
+// PrintLineDiff renders the added and removed lines of a text diff, along with the unchanged lines around them.
+func PrintLineDiff(lines []source.Line) {
+	const context = 2
+
+	changed := func(idx int) bool {
+		for near := max(0, idx-context); near <= min(len(lines)-1, idx+context); near++ {
+			if lines[near].Kind != source.LineUnchanged {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if !slices.ContainsFunc(lines, func(line source.Line) bool { return line.Kind != source.LineUnchanged }) {
+		TextSuccess("No changes")
+
+		return
+	}
+
+	skipped := false
+
+	for idx, line := range lines {
+		switch {
+		case line.Kind == source.LineAdded:
+			DiffAdd(line.Text)
+		case line.Kind == source.LineRemoved:
+			DiffMinus(line.Text)
+		case changed(idx):
+			pterm.DefaultBasicText.Printfln("  %s", line.Text)
+		case !skipped:
+			pterm.DefaultBasicText.Printfln("%s", pterm.Gray("  ..."))
+		}
+
+		skipped = line.Kind == source.LineUnchanged && !changed(idx)
+	}
+}
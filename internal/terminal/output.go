@@ -0,0 +1,99 @@
+package terminal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+)
+
+type OutputFormat string
+
+const (
+	OutputText OutputFormat = ""
+	OutputJSON OutputFormat = "json"
+	OutputYAML OutputFormat = "yaml"
+	OutputCSV  OutputFormat = "csv"
+)
+
+var ErrInvalidOutputFormat = errors.New("invalid output format")
+
+// ParseOutputFormat validates the value of an --output flag against the formats supported by a command.
+func ParseOutputFormat(value string, supported ...OutputFormat) (OutputFormat, error) {
+	if value == "" {
+		return OutputText, nil
+	}
+
+	for _, format := range supported {
+		if OutputFormat(value) == format {
+			return format, nil
+		}
+	}
+
+	return OutputText, fmt.Errorf("%w: %s", ErrInvalidOutputFormat, value)
+}
+
+// UseStderr sends all decorated output (text, warnings, tables, diffs) to stderr, keeping stdout free for
+// machine-readable output.
+func UseStderr() {
+	pterm.SetDefaultOutput(os.Stderr)
+
+	// The prefix printers keep the writer they were created with rather than following the default output
+	for _, printer := range []*pterm.PrefixPrinter{
+		&pterm.Info, &pterm.Success, &pterm.Warning, &pterm.Error, &pterm.Fatal, &pterm.Debug, &pterm.Description,
+	} {
+		printer.Writer = os.Stderr
+	}
+}
+
+// WriteStructured encodes v to stdout in the given machine-readable format.
+func WriteStructured(format OutputFormat, v any) error {
+	return EncodeStructured(os.Stdout, format, v)
+}
+
+func EncodeStructured(w io.Writer, format OutputFormat, v any) error {
+	switch format {
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode json output: %w", err)
+		}
+	case OutputYAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+
+		if err := encoder.Encode(v); err != nil {
+			return fmt.Errorf("failed to encode yaml output: %w", err)
+		}
+
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("failed to encode yaml output: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidOutputFormat, format)
+	}
+
+	return nil
+}
+
+// WriteCSV writes the header and rows to stdout as CSV.
+func WriteCSV(header []string, rows [][]string) error {
+	writer := csv.NewWriter(os.Stdout)
+
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write csv output: %w", err)
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write csv output: %w", err)
+	}
+
+	return nil
+}
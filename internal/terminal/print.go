@@ -59,14 +59,16 @@ func StartNewSpinner(message string) (*pterm.SpinnerPrinter, error) {
 	return spinner, nil
 }
 
-func DiffAdd(message string) {
-	pterm.DefaultBasicText.Printfln("%s", pterm.LightGreen("+ ", message))
+func PrintTable(header []string, rows [][]string) {
+	data := append([][]string{header}, rows...)
+
+	_ = pterm.DefaultTable.WithHasHeader().WithData(data).Render()
 }
 
-func DiffMinus(message string) {
-	pterm.DefaultBasicText.Printfln("%s", pterm.LightRed("- ", message))
+func TextError(message string) {
+	pterm.DefaultBasicText.Printfln("%s", pterm.Red(message, " ✗"))
 }
 
-func DiffModify(message string) {
-	pterm.DefaultBasicText.Printfln("%s", pterm.LightYellow("~ ", message))
+func TextHint(message string) {
+	pterm.DefaultBasicText.Printfln("%s", pterm.Gray("  → ", message))
 }
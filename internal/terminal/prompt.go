@@ -3,10 +3,12 @@ package terminal
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/manifoldco/promptui"
 	"github.com/pterm/pterm"
+	"golang.org/x/term"
 )
 
 var (
@@ -43,12 +45,25 @@ func PromptWithValidate(label, defaultValue string, fn func(s string) error) (st
 }
 
 func MultiSelect(name string, choices []string) ([]string, error) {
+	return MultiSelectDefault(name, choices, nil)
+}
+
+// MultiSelectDefault prompts the user to select choices, with the defaults already checked.
+func MultiSelectDefault(name string, choices, defaults []string) ([]string, error) {
 	model := selectModel{
 		title:    name,
 		choices:  choices,
 		selected: make(map[int]struct{}),
 		userQuit: make(map[int]struct{}),
 	}
+
+	for _, value := range defaults {
+		for idx, choice := range choices {
+			if choice == value {
+				model.selected[idx] = struct{}{}
+			}
+		}
+	}
 	p := tea.NewProgram(model)
 
 	_, err := p.Run()
@@ -60,9 +75,12 @@ func MultiSelect(name string, choices []string) ([]string, error) {
 		return nil, ErrUserQuit
 	}
 
+	// Keep the chosen options in the same order as the choices
 	var chosenOptions []string
-	for i := range model.selected {
-		chosenOptions = append(chosenOptions, choices[i])
+	for idx, choice := range choices {
+		if _, ok := model.selected[idx]; ok {
+			chosenOptions = append(chosenOptions, choice)
+		}
 	}
 
 	return chosenOptions, nil
@@ -164,3 +182,26 @@ func (m selectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// Note that we're not returning a command.
 	return m, nil
 }
+
+func Confirm(label string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     label,
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	if err != nil && errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	} else if err != nil && errors.Is(err, promptui.ErrInterrupt) {
+		return false, ErrUserQuit
+	} else if err != nil {
+		return false, fmt.Errorf("error running Confirm: %w", err)
+	}
+
+	return true, nil
+}
+
+// IsInteractive reports whether stdin is a terminal that prompts can read from.
+func IsInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -0,0 +1,167 @@
+package terminal
+
+import (
+	"errors"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/pterm/pterm"
+)
+
+const maxVisibleItems = 15
+
+var ErrNoChoices = errors.New("no choices available")
+
+// SelectItem is a single choice of FuzzySelect. Items sharing a Group are shown beneath a common heading, so they
+// should be passed in group order.
+type SelectItem struct {
+	Group       string
+	Label       string
+	Description string
+	// The text the query is fuzzy matched against, defaults to Label.
+	Search string
+}
+
+// FilterItems returns the indexes of the items that fuzzy match the query.
+func FilterItems(items []SelectItem, query string) []int {
+	matches := make([]int, 0, len(items))
+
+	for idx, item := range items {
+		search := item.Search
+		if search == "" {
+			search = item.Label
+		}
+
+		if fuzzy.MatchFold(query, search) {
+			matches = append(matches, idx)
+		}
+	}
+
+	return matches
+}
+
+// FuzzySelect lets the user pick a single item, filtering the items as they type. The query pre-fills the filter. The
+// index of the chosen item is returned.
+func FuzzySelect(title string, items []SelectItem, query string) (int, error) {
+	if len(items) == 0 {
+		return 0, ErrNoChoices
+	}
+
+	model := fuzzySelectModel{
+		title:   title,
+		items:   items,
+		query:   query,
+		matches: FilterItems(items, query),
+		chosen:  -1,
+	}
+
+	finalModel, err := tea.NewProgram(model).Run()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start FuzzySelect tea model: %w", err)
+	}
+
+	result, ok := finalModel.(fuzzySelectModel)
+	if !ok || result.chosen < 0 {
+		return 0, ErrUserQuit
+	}
+
+	return result.chosen, nil
+}
+
+type fuzzySelectModel struct {
+	title   string
+	items   []SelectItem
+	query   string
+	matches []int
+	cursor  int
+	chosen  int
+}
+
+func (m fuzzySelectModel) View() string {
+	text := fmt.Sprintf("%s: %s\n", m.title, pterm.Cyan(m.query+"_"))
+
+	if len(m.matches) == 0 {
+		text += pterm.Yellow("\nNo matches\n")
+	}
+
+	// Only render a window of the matches that keeps the cursor visible
+	start := 0
+	if m.cursor >= maxVisibleItems {
+		start = m.cursor - maxVisibleItems + 1
+	}
+
+	end := min(start+maxVisibleItems, len(m.matches))
+	group := ""
+
+	for idx := start; idx < end; idx++ {
+		item := m.items[m.matches[idx]]
+
+		if item.Group != group || idx == start {
+			group = item.Group
+			text += "\n" + pterm.Bold.Sprint(group) + "\n"
+		}
+
+		row := fmt.Sprintf("%s  %s", item.Label, pterm.Gray(item.Description))
+		if m.cursor == idx {
+			text += pterm.Green("> ", row) + "\n"
+		} else {
+			text += "  " + row + "\n"
+		}
+	}
+
+	// The footer
+	text += fmt.Sprintf("\n%d/%d matches. Type to filter, enter to select, esc to quit.\n", len(m.matches), len(m.items))
+
+	return text
+}
+
+func (m fuzzySelectModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m fuzzySelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	message, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	switch message.Type {
+	// These keys should exit the program.
+	case tea.KeyCtrlC, tea.KeyEsc:
+		return m, tea.Quit
+
+	case tea.KeyEnter:
+		if len(m.matches) > 0 {
+			m.chosen = m.matches[m.cursor]
+
+			return m, tea.Quit
+		}
+
+	case tea.KeyUp, tea.KeyCtrlP:
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case tea.KeyDown, tea.KeyCtrlN, tea.KeyTab:
+		if m.cursor < len(m.matches)-1 {
+			m.cursor++
+		}
+
+	// Editing the query re-filters the items and resets the cursor
+	case tea.KeyBackspace:
+		if len(m.query) > 0 {
+			runes := []rune(m.query)
+			m.query = string(runes[:len(runes)-1])
+			m.matches = FilterItems(m.items, m.query)
+			m.cursor = 0
+		}
+
+	case tea.KeyRunes, tea.KeySpace:
+		m.query += string(message.Runes)
+		m.matches = FilterItems(m.items, m.query)
+		m.cursor = 0
+	}
+
+	return m, nil
+}